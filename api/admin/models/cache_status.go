@@ -0,0 +1,50 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// CacheStatus cache status
+//
+// swagger:model CacheStatus
+type CacheStatus struct {
+
+	// Number of cached API keys.
+	APIKeys int64 `json:"apiKeys"`
+}
+
+// Validate validates this cache status
+func (m *CacheStatus) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this cache status based on context it is used
+func (m *CacheStatus) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *CacheStatus) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *CacheStatus) UnmarshalBinary(b []byte) error {
+	var res CacheStatus
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
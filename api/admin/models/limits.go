@@ -0,0 +1,56 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// Limits limits
+//
+// swagger:model Limits
+type Limits struct {
+
+	// Requests allowed at once.
+	Burst int64 `json:"burst,omitempty"`
+
+	// Requests per UTC day.
+	DailyQuota int64 `json:"dailyQuota,omitempty"`
+
+	// Requests per second.
+	Rate float64 `json:"rate,omitempty"`
+}
+
+// Validate validates this limits
+func (m *Limits) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this limits based on context it is used
+func (m *Limits) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *Limits) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *Limits) UnmarshalBinary(b []byte) error {
+	var res Limits
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
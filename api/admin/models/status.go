@@ -0,0 +1,255 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// Status status
+//
+// swagger:model Status
+type Status struct {
+
+	// Whether an instance sharing the database is importing.
+	ImportLocked bool `json:"importLocked"`
+
+	// import run
+	ImportRun *ImportRun `json:"importRun,omitempty"`
+
+	// The most recent imports, newest first.
+	Imports []*Import `json:"imports"`
+
+	// Number of prefixes filled from the upstream API by --upstream-fallback.
+	LazyPrefixes int64 `json:"lazyPrefixes"`
+
+	// refresh
+	Refresh *Refresh `json:"refresh,omitempty"`
+
+	// tables
+	Tables []*Table `json:"tables"`
+}
+
+// Validate validates this status
+func (m *Status) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateImportRun(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateImports(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateRefresh(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateTables(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *Status) validateImportRun(formats strfmt.Registry) error {
+	if swag.IsZero(m.ImportRun) { // not required
+		return nil
+	}
+
+	if m.ImportRun != nil {
+		if err := m.ImportRun.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("importRun")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Status) validateImports(formats strfmt.Registry) error {
+	if swag.IsZero(m.Imports) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Imports); i++ {
+		if swag.IsZero(m.Imports[i]) { // not required
+			continue
+		}
+
+		if m.Imports[i] != nil {
+			if err := m.Imports[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("imports" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Status) validateRefresh(formats strfmt.Registry) error {
+	if swag.IsZero(m.Refresh) { // not required
+		return nil
+	}
+
+	if m.Refresh != nil {
+		if err := m.Refresh.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("refresh")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Status) validateTables(formats strfmt.Registry) error {
+	if swag.IsZero(m.Tables) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Tables); i++ {
+		if swag.IsZero(m.Tables[i]) { // not required
+			continue
+		}
+
+		if m.Tables[i] != nil {
+			if err := m.Tables[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("tables" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// ContextValidate validate this status based on the context it is used
+func (m *Status) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateImportRun(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateImports(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateRefresh(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateTables(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *Status) contextValidateImportRun(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.ImportRun != nil {
+		if err := m.ImportRun.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("importRun")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Status) contextValidateImports(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Imports); i++ {
+
+		if m.Imports[i] != nil {
+			if err := m.Imports[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("imports" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *Status) contextValidateRefresh(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Refresh != nil {
+		if err := m.Refresh.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("refresh")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Status) contextValidateTables(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Tables); i++ {
+
+		if m.Tables[i] != nil {
+			if err := m.Tables[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("tables" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *Status) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *Status) UnmarshalBinary(b []byte) error {
+	var res Status
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
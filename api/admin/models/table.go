@@ -0,0 +1,53 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"context"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+)
+
+// Table table
+//
+// swagger:model Table
+type Table struct {
+
+	// Row estimate of the Postgres statistics.
+	EstimatedRows int64 `json:"estimatedRows"`
+
+	// name
+	Name string `json:"name,omitempty"`
+}
+
+// Validate validates this table
+func (m *Table) Validate(formats strfmt.Registry) error {
+	return nil
+}
+
+// ContextValidate validates this table based on context it is used
+func (m *Table) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *Table) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *Table) UnmarshalBinary(b []byte) error {
+	var res Table
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
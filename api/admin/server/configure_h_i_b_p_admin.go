@@ -0,0 +1,143 @@
+// This file is safe to edit. Once it exists it will not be overwritten
+
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/api/admin/server/restapi"
+	"github.com/leesalminen/hibp/api/admin/server/restapi/cache"
+	"github.com/leesalminen/hibp/api/admin/server/restapi/imports"
+	"github.com/leesalminen/hibp/api/admin/server/restapi/keys"
+	"github.com/leesalminen/hibp/api/admin/server/restapi/status"
+	"github.com/leesalminen/hibp/model"
+)
+
+//go:generate swagger generate server --target ../../admin --name HIBPAdmin --spec ../../../.swagger/admin.swagger.yaml --api-package restapi --server-package server --principal github.com/leesalminen/hibp/model.Principal --exclude-main
+
+func configureFlags(api *restapi.HIBPAdminAPI) {
+	// api.CommandLineOptionsGroups = []swag.CommandLineOptionsGroup{ ... }
+}
+
+func configureAPI(api *restapi.HIBPAdminAPI) http.Handler {
+	// configure the api here
+	api.ServeError = errors.ServeError
+
+	// Set your custom logger if needed. Default one is log.Printf
+	// Expected interface func(string, ...interface{})
+	//
+	// Example:
+	// api.Logger = log.Printf
+
+	api.UseSwaggerUI()
+	// To continue using redoc as your UI, uncomment the following line
+	// api.UseRedoc()
+
+	api.JSONConsumer = runtime.JSONConsumer()
+
+	api.JSONProducer = runtime.JSONProducer()
+
+	// Applies when the "hibp-admin-token" header is set
+	if api.AdminTokenAuth == nil {
+		api.AdminTokenAuth = func(token string) (*model.Principal, error) {
+			return nil, errors.NotImplemented("api key auth (admin_token) hibp-admin-token from header param [hibp-admin-token] has not yet been implemented")
+		}
+	}
+
+	// Set your custom authorizer if needed. Default one is security.Authorized()
+	// Expected interface runtime.Authorizer
+	//
+	// Example:
+	// api.APIAuthorizer = security.Authorized()
+
+	if api.KeysCreateKeyHandler == nil {
+		api.KeysCreateKeyHandler = keys.CreateKeyHandlerFunc(func(params keys.CreateKeyParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation keys.CreateKey has not yet been implemented")
+		})
+	}
+	if api.KeysDisableKeyHandler == nil {
+		api.KeysDisableKeyHandler = keys.DisableKeyHandlerFunc(func(params keys.DisableKeyParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation keys.DisableKey has not yet been implemented")
+		})
+	}
+	if api.KeysEnableKeyHandler == nil {
+		api.KeysEnableKeyHandler = keys.EnableKeyHandlerFunc(func(params keys.EnableKeyParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation keys.EnableKey has not yet been implemented")
+		})
+	}
+	if api.CacheFlushCacheHandler == nil {
+		api.CacheFlushCacheHandler = cache.FlushCacheHandlerFunc(func(params cache.FlushCacheParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation cache.FlushCache has not yet been implemented")
+		})
+	}
+	if api.CacheGetCacheHandler == nil {
+		api.CacheGetCacheHandler = cache.GetCacheHandlerFunc(func(params cache.GetCacheParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation cache.GetCache has not yet been implemented")
+		})
+	}
+	if api.ImportsGetImportHandler == nil {
+		api.ImportsGetImportHandler = imports.GetImportHandlerFunc(func(params imports.GetImportParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation imports.GetImport has not yet been implemented")
+		})
+	}
+	if api.StatusGetStatusHandler == nil {
+		api.StatusGetStatusHandler = status.GetStatusHandlerFunc(func(params status.GetStatusParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation status.GetStatus has not yet been implemented")
+		})
+	}
+	if api.KeysListKeysHandler == nil {
+		api.KeysListKeysHandler = keys.ListKeysHandlerFunc(func(params keys.ListKeysParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation keys.ListKeys has not yet been implemented")
+		})
+	}
+	if api.KeysRevokeKeyHandler == nil {
+		api.KeysRevokeKeyHandler = keys.RevokeKeyHandlerFunc(func(params keys.RevokeKeyParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation keys.RevokeKey has not yet been implemented")
+		})
+	}
+	if api.KeysSetKeyLimitsHandler == nil {
+		api.KeysSetKeyLimitsHandler = keys.SetKeyLimitsHandlerFunc(func(params keys.SetKeyLimitsParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation keys.SetKeyLimits has not yet been implemented")
+		})
+	}
+	if api.ImportsStartImportHandler == nil {
+		api.ImportsStartImportHandler = imports.StartImportHandlerFunc(func(params imports.StartImportParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation imports.StartImport has not yet been implemented")
+		})
+	}
+
+	api.PreServerShutdown = func() {}
+
+	api.ServerShutdown = func() {}
+
+	return setupGlobalMiddleware(api.Serve(setupMiddlewares))
+}
+
+// The TLS configuration before HTTPS server starts.
+func configureTLS(tlsConfig *tls.Config) {
+	// Make all necessary changes to the TLS configuration here.
+}
+
+// As soon as server is initialized but not run yet, this function will be called.
+// If you need to modify a config, store server instance to stop it individually later, this is the place.
+// This function can be called multiple times, depending on the number of serving schemes.
+// scheme value will be set accordingly: "http", "https" or "unix".
+func configureServer(s *http.Server, scheme, addr string) {
+}
+
+// The middleware configuration is for the handler executors. These do not apply to the swagger.json document.
+// The middleware executes after routing but before authentication, binding and validation.
+func setupMiddlewares(handler http.Handler) http.Handler {
+	return handler
+}
+
+// The middleware configuration happens before anything, this middleware also applies to serving the swagger.json document.
+// So this is a good place to plug in a panic handling middleware, logging and metrics.
+func setupGlobalMiddleware(handler http.Handler) http.Handler {
+	return handler
+}
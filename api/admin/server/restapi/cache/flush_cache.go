@@ -0,0 +1,71 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package cache
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// FlushCacheHandlerFunc turns a function with the right signature into a flush cache handler
+type FlushCacheHandlerFunc func(FlushCacheParams, *model.Principal) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn FlushCacheHandlerFunc) Handle(params FlushCacheParams, principal *model.Principal) middleware.Responder {
+	return fn(params, principal)
+}
+
+// FlushCacheHandler interface for that can handle valid flush cache params
+type FlushCacheHandler interface {
+	Handle(FlushCacheParams, *model.Principal) middleware.Responder
+}
+
+// NewFlushCache creates a new http.Handler for the flush cache operation
+func NewFlushCache(ctx *middleware.Context, handler FlushCacheHandler) *FlushCache {
+	return &FlushCache{Context: ctx, Handler: handler}
+}
+
+/* FlushCache swagger:route DELETE /cache cache flushCache
+
+Drop all cached API keys, so disabled and revoked keys are rejected immediately.
+
+*/
+type FlushCache struct {
+	Context *middleware.Context
+	Handler FlushCacheHandler
+}
+
+func (o *FlushCache) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		r = rCtx
+	}
+	var Params = NewFlushCacheParams()
+	uprinc, aCtx, err := o.Context.Authorize(r, route)
+	if err != nil {
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+	if aCtx != nil {
+		r = aCtx
+	}
+	var principal *model.Principal
+	if uprinc != nil {
+		principal = uprinc.(*model.Principal) // this is really a model.Principal, I promise
+	}
+
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params, principal) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
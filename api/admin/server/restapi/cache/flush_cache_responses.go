@@ -0,0 +1,82 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package cache
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/leesalminen/hibp/api/admin/models"
+)
+
+// FlushCacheNoContentCode is the HTTP code returned for type FlushCacheNoContent
+const FlushCacheNoContentCode int = 204
+
+/*FlushCacheNoContent The cache was flushed.
+
+swagger:response flushCacheNoContent
+*/
+type FlushCacheNoContent struct {
+}
+
+// NewFlushCacheNoContent creates FlushCacheNoContent with default headers values
+func NewFlushCacheNoContent() *FlushCacheNoContent {
+
+	return &FlushCacheNoContent{}
+}
+
+// WriteResponse to the client
+func (o *FlushCacheNoContent) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.Header().Del(runtime.HeaderContentType) //Remove Content-Type on empty responses
+
+	rw.WriteHeader(204)
+}
+
+// FlushCacheUnauthorizedCode is the HTTP code returned for type FlushCacheUnauthorized
+const FlushCacheUnauthorizedCode int = 401
+
+/*FlushCacheUnauthorized The admin token is missing or invalid.
+
+swagger:response flushCacheUnauthorized
+*/
+type FlushCacheUnauthorized struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewFlushCacheUnauthorized creates FlushCacheUnauthorized with default headers values
+func NewFlushCacheUnauthorized() *FlushCacheUnauthorized {
+
+	return &FlushCacheUnauthorized{}
+}
+
+// WithPayload adds the payload to the flush cache unauthorized response
+func (o *FlushCacheUnauthorized) WithPayload(payload *models.Error) *FlushCacheUnauthorized {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the flush cache unauthorized response
+func (o *FlushCacheUnauthorized) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *FlushCacheUnauthorized) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(401)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
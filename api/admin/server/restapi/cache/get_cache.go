@@ -0,0 +1,71 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package cache
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// GetCacheHandlerFunc turns a function with the right signature into a get cache handler
+type GetCacheHandlerFunc func(GetCacheParams, *model.Principal) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn GetCacheHandlerFunc) Handle(params GetCacheParams, principal *model.Principal) middleware.Responder {
+	return fn(params, principal)
+}
+
+// GetCacheHandler interface for that can handle valid get cache params
+type GetCacheHandler interface {
+	Handle(GetCacheParams, *model.Principal) middleware.Responder
+}
+
+// NewGetCache creates a new http.Handler for the get cache operation
+func NewGetCache(ctx *middleware.Context, handler GetCacheHandler) *GetCache {
+	return &GetCache{Context: ctx, Handler: handler}
+}
+
+/* GetCache swagger:route GET /cache cache getCache
+
+Size of the API key cache.
+
+*/
+type GetCache struct {
+	Context *middleware.Context
+	Handler GetCacheHandler
+}
+
+func (o *GetCache) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		r = rCtx
+	}
+	var Params = NewGetCacheParams()
+	uprinc, aCtx, err := o.Context.Authorize(r, route)
+	if err != nil {
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+	if aCtx != nil {
+		r = aCtx
+	}
+	var principal *model.Principal
+	if uprinc != nil {
+		principal = uprinc.(*model.Principal) // this is really a model.Principal, I promise
+	}
+
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params, principal) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
@@ -0,0 +1,102 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package cache
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/leesalminen/hibp/api/admin/models"
+)
+
+// GetCacheOKCode is the HTTP code returned for type GetCacheOK
+const GetCacheOKCode int = 200
+
+/*GetCacheOK Cache status.
+
+swagger:response getCacheOK
+*/
+type GetCacheOK struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.CacheStatus `json:"body,omitempty"`
+}
+
+// NewGetCacheOK creates GetCacheOK with default headers values
+func NewGetCacheOK() *GetCacheOK {
+
+	return &GetCacheOK{}
+}
+
+// WithPayload adds the payload to the get cache o k response
+func (o *GetCacheOK) WithPayload(payload *models.CacheStatus) *GetCacheOK {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get cache o k response
+func (o *GetCacheOK) SetPayload(payload *models.CacheStatus) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetCacheOK) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(200)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// GetCacheUnauthorizedCode is the HTTP code returned for type GetCacheUnauthorized
+const GetCacheUnauthorizedCode int = 401
+
+/*GetCacheUnauthorized The admin token is missing or invalid.
+
+swagger:response getCacheUnauthorized
+*/
+type GetCacheUnauthorized struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewGetCacheUnauthorized creates GetCacheUnauthorized with default headers values
+func NewGetCacheUnauthorized() *GetCacheUnauthorized {
+
+	return &GetCacheUnauthorized{}
+}
+
+// WithPayload adds the payload to the get cache unauthorized response
+func (o *GetCacheUnauthorized) WithPayload(payload *models.Error) *GetCacheUnauthorized {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get cache unauthorized response
+func (o *GetCacheUnauthorized) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetCacheUnauthorized) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(401)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
@@ -0,0 +1,469 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package restapi
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/runtime/security"
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+
+	"github.com/leesalminen/hibp/api/admin/server/restapi/cache"
+	"github.com/leesalminen/hibp/api/admin/server/restapi/imports"
+	"github.com/leesalminen/hibp/api/admin/server/restapi/keys"
+	"github.com/leesalminen/hibp/api/admin/server/restapi/reload"
+	"github.com/leesalminen/hibp/api/admin/server/restapi/status"
+	"github.com/leesalminen/hibp/model"
+)
+
+// NewHIBPAdminAPI creates a new HIBPAdmin instance
+func NewHIBPAdminAPI(spec *loads.Document) *HIBPAdminAPI {
+	return &HIBPAdminAPI{
+		handlers:            make(map[string]map[string]http.Handler),
+		formats:             strfmt.Default,
+		defaultConsumes:     "application/json",
+		defaultProduces:     "application/json",
+		customConsumers:     make(map[string]runtime.Consumer),
+		customProducers:     make(map[string]runtime.Producer),
+		PreServerShutdown:   func() {},
+		ServerShutdown:      func() {},
+		spec:                spec,
+		useSwaggerUI:        false,
+		ServeError:          errors.ServeError,
+		BasicAuthenticator:  security.BasicAuth,
+		APIKeyAuthenticator: security.APIKeyAuth,
+		BearerAuthenticator: security.BearerAuth,
+
+		JSONConsumer: runtime.JSONConsumer(),
+
+		JSONProducer: runtime.JSONProducer(),
+
+		KeysCreateKeyHandler: keys.CreateKeyHandlerFunc(func(params keys.CreateKeyParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation keys.CreateKey has not yet been implemented")
+		}),
+		KeysDisableKeyHandler: keys.DisableKeyHandlerFunc(func(params keys.DisableKeyParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation keys.DisableKey has not yet been implemented")
+		}),
+		KeysEnableKeyHandler: keys.EnableKeyHandlerFunc(func(params keys.EnableKeyParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation keys.EnableKey has not yet been implemented")
+		}),
+		CacheFlushCacheHandler: cache.FlushCacheHandlerFunc(func(params cache.FlushCacheParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation cache.FlushCache has not yet been implemented")
+		}),
+		CacheGetCacheHandler: cache.GetCacheHandlerFunc(func(params cache.GetCacheParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation cache.GetCache has not yet been implemented")
+		}),
+		ImportsGetImportHandler: imports.GetImportHandlerFunc(func(params imports.GetImportParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation imports.GetImport has not yet been implemented")
+		}),
+		StatusGetStatusHandler: status.GetStatusHandlerFunc(func(params status.GetStatusParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation status.GetStatus has not yet been implemented")
+		}),
+		KeysListKeysHandler: keys.ListKeysHandlerFunc(func(params keys.ListKeysParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation keys.ListKeys has not yet been implemented")
+		}),
+		ReloadReloadHandler: reload.ReloadHandlerFunc(func(params reload.ReloadParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation reload.Reload has not yet been implemented")
+		}),
+		KeysRevokeKeyHandler: keys.RevokeKeyHandlerFunc(func(params keys.RevokeKeyParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation keys.RevokeKey has not yet been implemented")
+		}),
+		KeysSetKeyLimitsHandler: keys.SetKeyLimitsHandlerFunc(func(params keys.SetKeyLimitsParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation keys.SetKeyLimits has not yet been implemented")
+		}),
+		ImportsStartImportHandler: imports.StartImportHandlerFunc(func(params imports.StartImportParams, principal *model.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation imports.StartImport has not yet been implemented")
+		}),
+
+		// Applies when the "hibp-admin-token" header is set
+		AdminTokenAuth: func(token string) (*model.Principal, error) {
+			return nil, errors.NotImplemented("api key auth (admin_token) hibp-admin-token from header param [hibp-admin-token] has not yet been implemented")
+		},
+		// default authorizer is authorized meaning no requests are blocked
+		APIAuthorizer: security.Authorized(),
+	}
+}
+
+/*HIBPAdminAPI Administration of the self-hosted HIBP password hash checker, served on the --admin-listen address */
+type HIBPAdminAPI struct {
+	spec            *loads.Document
+	context         *middleware.Context
+	handlers        map[string]map[string]http.Handler
+	formats         strfmt.Registry
+	customConsumers map[string]runtime.Consumer
+	customProducers map[string]runtime.Producer
+	defaultConsumes string
+	defaultProduces string
+	Middleware      func(middleware.Builder) http.Handler
+	useSwaggerUI    bool
+
+	// BasicAuthenticator generates a runtime.Authenticator from the supplied basic auth function.
+	// It has a default implementation in the security package, however you can replace it for your particular usage.
+	BasicAuthenticator func(security.UserPassAuthentication) runtime.Authenticator
+
+	// APIKeyAuthenticator generates a runtime.Authenticator from the supplied token auth function.
+	// It has a default implementation in the security package, however you can replace it for your particular usage.
+	APIKeyAuthenticator func(string, string, security.TokenAuthentication) runtime.Authenticator
+
+	// BearerAuthenticator generates a runtime.Authenticator from the supplied bearer token auth function.
+	// It has a default implementation in the security package, however you can replace it for your particular usage.
+	BearerAuthenticator func(string, security.ScopedTokenAuthentication) runtime.Authenticator
+
+	// JSONConsumer registers a consumer for the following mime types:
+	//   - application/json
+	JSONConsumer runtime.Consumer
+
+	// JSONProducer registers a producer for the following mime types:
+	//   - application/json
+	JSONProducer runtime.Producer
+
+	// AdminTokenAuth registers a function that takes a token and returns a principal
+	// it performs authentication based on an api key hibp-admin-token provided in the header
+	AdminTokenAuth func(string) (*model.Principal, error)
+
+	// APIAuthorizer provides access control (ACL/RBAC/ABAC) by providing access to the request and authenticated principal
+	APIAuthorizer runtime.Authorizer
+
+	// KeysCreateKeyHandler sets the operation handler for the create key operation
+	KeysCreateKeyHandler keys.CreateKeyHandler
+	// KeysDisableKeyHandler sets the operation handler for the disable key operation
+	KeysDisableKeyHandler keys.DisableKeyHandler
+	// KeysEnableKeyHandler sets the operation handler for the enable key operation
+	KeysEnableKeyHandler keys.EnableKeyHandler
+	// CacheFlushCacheHandler sets the operation handler for the flush cache operation
+	CacheFlushCacheHandler cache.FlushCacheHandler
+	// CacheGetCacheHandler sets the operation handler for the get cache operation
+	CacheGetCacheHandler cache.GetCacheHandler
+	// ImportsGetImportHandler sets the operation handler for the get import operation
+	ImportsGetImportHandler imports.GetImportHandler
+	// StatusGetStatusHandler sets the operation handler for the get status operation
+	StatusGetStatusHandler status.GetStatusHandler
+	// KeysListKeysHandler sets the operation handler for the list keys operation
+	KeysListKeysHandler keys.ListKeysHandler
+	// ReloadReloadHandler sets the operation handler for the reload operation
+	ReloadReloadHandler reload.ReloadHandler
+	// KeysRevokeKeyHandler sets the operation handler for the revoke key operation
+	KeysRevokeKeyHandler keys.RevokeKeyHandler
+	// KeysSetKeyLimitsHandler sets the operation handler for the set key limits operation
+	KeysSetKeyLimitsHandler keys.SetKeyLimitsHandler
+	// ImportsStartImportHandler sets the operation handler for the start import operation
+	ImportsStartImportHandler imports.StartImportHandler
+
+	// ServeError is called when an error is received, there is a default handler
+	// but you can set your own with this
+	ServeError func(http.ResponseWriter, *http.Request, error)
+
+	// PreServerShutdown is called before the HTTP(S) server is shutdown
+	// This allows for custom functions to get executed before the HTTP(S) server stops accepting traffic
+	PreServerShutdown func()
+
+	// ServerShutdown is called when the HTTP(S) server is shut down and done
+	// handling all active connections and does not accept connections any more
+	ServerShutdown func()
+
+	// Custom command line argument groups with their descriptions
+	CommandLineOptionsGroups []swag.CommandLineOptionsGroup
+
+	// User defined logger function.
+	Logger func(string, ...interface{})
+}
+
+// UseRedoc for documentation at /docs
+func (o *HIBPAdminAPI) UseRedoc() {
+	o.useSwaggerUI = false
+}
+
+// UseSwaggerUI for documentation at /docs
+func (o *HIBPAdminAPI) UseSwaggerUI() {
+	o.useSwaggerUI = true
+}
+
+// SetDefaultProduces sets the default produces media type
+func (o *HIBPAdminAPI) SetDefaultProduces(mediaType string) {
+	o.defaultProduces = mediaType
+}
+
+// SetDefaultConsumes returns the default consumes media type
+func (o *HIBPAdminAPI) SetDefaultConsumes(mediaType string) {
+	o.defaultConsumes = mediaType
+}
+
+// SetSpec sets a spec that will be served for the clients.
+func (o *HIBPAdminAPI) SetSpec(spec *loads.Document) {
+	o.spec = spec
+}
+
+// DefaultProduces returns the default produces media type
+func (o *HIBPAdminAPI) DefaultProduces() string {
+	return o.defaultProduces
+}
+
+// DefaultConsumes returns the default consumes media type
+func (o *HIBPAdminAPI) DefaultConsumes() string {
+	return o.defaultConsumes
+}
+
+// Formats returns the registered string formats
+func (o *HIBPAdminAPI) Formats() strfmt.Registry {
+	return o.formats
+}
+
+// RegisterFormat registers a custom format validator
+func (o *HIBPAdminAPI) RegisterFormat(name string, format strfmt.Format, validator strfmt.Validator) {
+	o.formats.Add(name, format, validator)
+}
+
+// Validate validates the registrations in the HIBPAdminAPI
+func (o *HIBPAdminAPI) Validate() error {
+	var unregistered []string
+
+	if o.JSONConsumer == nil {
+		unregistered = append(unregistered, "JSONConsumer")
+	}
+
+	if o.JSONProducer == nil {
+		unregistered = append(unregistered, "JSONProducer")
+	}
+
+	if o.AdminTokenAuth == nil {
+		unregistered = append(unregistered, "HibpAdminTokenAuth")
+	}
+
+	if o.KeysCreateKeyHandler == nil {
+		unregistered = append(unregistered, "keys.CreateKeyHandler")
+	}
+	if o.KeysDisableKeyHandler == nil {
+		unregistered = append(unregistered, "keys.DisableKeyHandler")
+	}
+	if o.KeysEnableKeyHandler == nil {
+		unregistered = append(unregistered, "keys.EnableKeyHandler")
+	}
+	if o.CacheFlushCacheHandler == nil {
+		unregistered = append(unregistered, "cache.FlushCacheHandler")
+	}
+	if o.CacheGetCacheHandler == nil {
+		unregistered = append(unregistered, "cache.GetCacheHandler")
+	}
+	if o.ImportsGetImportHandler == nil {
+		unregistered = append(unregistered, "imports.GetImportHandler")
+	}
+	if o.StatusGetStatusHandler == nil {
+		unregistered = append(unregistered, "status.GetStatusHandler")
+	}
+	if o.KeysListKeysHandler == nil {
+		unregistered = append(unregistered, "keys.ListKeysHandler")
+	}
+	if o.ReloadReloadHandler == nil {
+		unregistered = append(unregistered, "reload.ReloadHandler")
+	}
+	if o.KeysRevokeKeyHandler == nil {
+		unregistered = append(unregistered, "keys.RevokeKeyHandler")
+	}
+	if o.KeysSetKeyLimitsHandler == nil {
+		unregistered = append(unregistered, "keys.SetKeyLimitsHandler")
+	}
+	if o.ImportsStartImportHandler == nil {
+		unregistered = append(unregistered, "imports.StartImportHandler")
+	}
+
+	if len(unregistered) > 0 {
+		return fmt.Errorf("missing registration: %s", strings.Join(unregistered, ", "))
+	}
+
+	return nil
+}
+
+// ServeErrorFor gets a error handler for a given operation id
+func (o *HIBPAdminAPI) ServeErrorFor(operationID string) func(http.ResponseWriter, *http.Request, error) {
+	return o.ServeError
+}
+
+// AuthenticatorsFor gets the authenticators for the specified security schemes
+func (o *HIBPAdminAPI) AuthenticatorsFor(schemes map[string]spec.SecurityScheme) map[string]runtime.Authenticator {
+	result := make(map[string]runtime.Authenticator)
+	for name := range schemes {
+		switch name {
+		case "admin_token":
+			scheme := schemes[name]
+			result[name] = o.APIKeyAuthenticator(scheme.Name, scheme.In, func(token string) (interface{}, error) {
+				return o.AdminTokenAuth(token)
+			})
+
+		}
+	}
+	return result
+}
+
+// Authorizer returns the registered authorizer
+func (o *HIBPAdminAPI) Authorizer() runtime.Authorizer {
+	return o.APIAuthorizer
+}
+
+// ConsumersFor gets the consumers for the specified media types.
+// MIME type parameters are ignored here.
+func (o *HIBPAdminAPI) ConsumersFor(mediaTypes []string) map[string]runtime.Consumer {
+	result := make(map[string]runtime.Consumer, len(mediaTypes))
+	for _, mt := range mediaTypes {
+		switch mt {
+		case "application/json":
+			result["application/json"] = o.JSONConsumer
+		}
+
+		if c, ok := o.customConsumers[mt]; ok {
+			result[mt] = c
+		}
+	}
+	return result
+}
+
+// ProducersFor gets the producers for the specified media types.
+// MIME type parameters are ignored here.
+func (o *HIBPAdminAPI) ProducersFor(mediaTypes []string) map[string]runtime.Producer {
+	result := make(map[string]runtime.Producer, len(mediaTypes))
+	for _, mt := range mediaTypes {
+		switch mt {
+		case "application/json":
+			result["application/json"] = o.JSONProducer
+		}
+
+		if p, ok := o.customProducers[mt]; ok {
+			result[mt] = p
+		}
+	}
+	return result
+}
+
+// HandlerFor gets a http.Handler for the provided operation method and path
+func (o *HIBPAdminAPI) HandlerFor(method, path string) (http.Handler, bool) {
+	if o.handlers == nil {
+		return nil, false
+	}
+	um := strings.ToUpper(method)
+	if _, ok := o.handlers[um]; !ok {
+		return nil, false
+	}
+	if path == "/" {
+		path = ""
+	}
+	h, ok := o.handlers[um][path]
+	return h, ok
+}
+
+// Context returns the middleware context for the h i b p admin API
+func (o *HIBPAdminAPI) Context() *middleware.Context {
+	if o.context == nil {
+		o.context = middleware.NewRoutableContext(o.spec, o, nil)
+	}
+
+	return o.context
+}
+
+func (o *HIBPAdminAPI) initHandlerCache() {
+	o.Context() // don't care about the result, just that the initialization happened
+	if o.handlers == nil {
+		o.handlers = make(map[string]map[string]http.Handler)
+	}
+
+	if o.handlers["POST"] == nil {
+		o.handlers["POST"] = make(map[string]http.Handler)
+	}
+	o.handlers["POST"]["/keys"] = keys.NewCreateKey(o.context, o.KeysCreateKeyHandler)
+	if o.handlers["POST"] == nil {
+		o.handlers["POST"] = make(map[string]http.Handler)
+	}
+	o.handlers["POST"]["/keys/{name}/disable"] = keys.NewDisableKey(o.context, o.KeysDisableKeyHandler)
+	if o.handlers["POST"] == nil {
+		o.handlers["POST"] = make(map[string]http.Handler)
+	}
+	o.handlers["POST"]["/keys/{name}/enable"] = keys.NewEnableKey(o.context, o.KeysEnableKeyHandler)
+	if o.handlers["DELETE"] == nil {
+		o.handlers["DELETE"] = make(map[string]http.Handler)
+	}
+	o.handlers["DELETE"]["/cache"] = cache.NewFlushCache(o.context, o.CacheFlushCacheHandler)
+	if o.handlers["GET"] == nil {
+		o.handlers["GET"] = make(map[string]http.Handler)
+	}
+	o.handlers["GET"]["/cache"] = cache.NewGetCache(o.context, o.CacheGetCacheHandler)
+	if o.handlers["GET"] == nil {
+		o.handlers["GET"] = make(map[string]http.Handler)
+	}
+	o.handlers["GET"]["/import"] = imports.NewGetImport(o.context, o.ImportsGetImportHandler)
+	if o.handlers["GET"] == nil {
+		o.handlers["GET"] = make(map[string]http.Handler)
+	}
+	o.handlers["GET"]["/status"] = status.NewGetStatus(o.context, o.StatusGetStatusHandler)
+	if o.handlers["GET"] == nil {
+		o.handlers["GET"] = make(map[string]http.Handler)
+	}
+	o.handlers["GET"]["/keys"] = keys.NewListKeys(o.context, o.KeysListKeysHandler)
+	if o.handlers["POST"] == nil {
+		o.handlers["POST"] = make(map[string]http.Handler)
+	}
+	o.handlers["POST"]["/reload"] = reload.NewReload(o.context, o.ReloadReloadHandler)
+	if o.handlers["DELETE"] == nil {
+		o.handlers["DELETE"] = make(map[string]http.Handler)
+	}
+	o.handlers["DELETE"]["/keys/{name}"] = keys.NewRevokeKey(o.context, o.KeysRevokeKeyHandler)
+	if o.handlers["PUT"] == nil {
+		o.handlers["PUT"] = make(map[string]http.Handler)
+	}
+	o.handlers["PUT"]["/keys/{name}/limits"] = keys.NewSetKeyLimits(o.context, o.KeysSetKeyLimitsHandler)
+	if o.handlers["POST"] == nil {
+		o.handlers["POST"] = make(map[string]http.Handler)
+	}
+	o.handlers["POST"]["/import"] = imports.NewStartImport(o.context, o.ImportsStartImportHandler)
+}
+
+// Serve creates a http handler to serve the API over HTTP
+// can be used directly in http.ListenAndServe(":8000", api.Serve(nil))
+func (o *HIBPAdminAPI) Serve(builder middleware.Builder) http.Handler {
+	o.Init()
+
+	if o.Middleware != nil {
+		return o.Middleware(builder)
+	}
+	if o.useSwaggerUI {
+		return o.context.APIHandlerSwaggerUI(builder)
+	}
+	return o.context.APIHandler(builder)
+}
+
+// Init allows you to just initialize the handler cache, you can then recompose the middleware as you see fit
+func (o *HIBPAdminAPI) Init() {
+	if len(o.handlers) == 0 {
+		o.initHandlerCache()
+	}
+}
+
+// RegisterConsumer allows you to add (or override) a consumer for a media type.
+func (o *HIBPAdminAPI) RegisterConsumer(mediaType string, consumer runtime.Consumer) {
+	o.customConsumers[mediaType] = consumer
+}
+
+// RegisterProducer allows you to add (or override) a producer for a media type.
+func (o *HIBPAdminAPI) RegisterProducer(mediaType string, producer runtime.Producer) {
+	o.customProducers[mediaType] = producer
+}
+
+// AddMiddlewareFor adds a http middleware to existing handler
+func (o *HIBPAdminAPI) AddMiddlewareFor(method, path string, builder middleware.Builder) {
+	um := strings.ToUpper(method)
+	if path == "/" {
+		path = ""
+	}
+	o.Init()
+	if h, ok := o.handlers[um][path]; ok {
+		o.handlers[method][path] = builder(h)
+	}
+}
@@ -0,0 +1,71 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package imports
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// GetImportHandlerFunc turns a function with the right signature into a get import handler
+type GetImportHandlerFunc func(GetImportParams, *model.Principal) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn GetImportHandlerFunc) Handle(params GetImportParams, principal *model.Principal) middleware.Responder {
+	return fn(params, principal)
+}
+
+// GetImportHandler interface for that can handle valid get import params
+type GetImportHandler interface {
+	Handle(GetImportParams, *model.Principal) middleware.Responder
+}
+
+// NewGetImport creates a new http.Handler for the get import operation
+func NewGetImport(ctx *middleware.Context, handler GetImportHandler) *GetImport {
+	return &GetImport{Context: ctx, Handler: handler}
+}
+
+/* GetImport swagger:route GET /import imports getImport
+
+State of the last import triggered on this instance.
+
+*/
+type GetImport struct {
+	Context *middleware.Context
+	Handler GetImportHandler
+}
+
+func (o *GetImport) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		r = rCtx
+	}
+	var Params = NewGetImportParams()
+	uprinc, aCtx, err := o.Context.Authorize(r, route)
+	if err != nil {
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+	if aCtx != nil {
+		r = aCtx
+	}
+	var principal *model.Principal
+	if uprinc != nil {
+		principal = uprinc.(*model.Principal) // this is really a model.Principal, I promise
+	}
+
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params, principal) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
@@ -0,0 +1,102 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package imports
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/leesalminen/hibp/api/admin/models"
+)
+
+// GetImportOKCode is the HTTP code returned for type GetImportOK
+const GetImportOKCode int = 200
+
+/*GetImportOK Import state.
+
+swagger:response getImportOK
+*/
+type GetImportOK struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.ImportRun `json:"body,omitempty"`
+}
+
+// NewGetImportOK creates GetImportOK with default headers values
+func NewGetImportOK() *GetImportOK {
+
+	return &GetImportOK{}
+}
+
+// WithPayload adds the payload to the get import o k response
+func (o *GetImportOK) WithPayload(payload *models.ImportRun) *GetImportOK {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get import o k response
+func (o *GetImportOK) SetPayload(payload *models.ImportRun) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetImportOK) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(200)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// GetImportUnauthorizedCode is the HTTP code returned for type GetImportUnauthorized
+const GetImportUnauthorizedCode int = 401
+
+/*GetImportUnauthorized The admin token is missing or invalid.
+
+swagger:response getImportUnauthorized
+*/
+type GetImportUnauthorized struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewGetImportUnauthorized creates GetImportUnauthorized with default headers values
+func NewGetImportUnauthorized() *GetImportUnauthorized {
+
+	return &GetImportUnauthorized{}
+}
+
+// WithPayload adds the payload to the get import unauthorized response
+func (o *GetImportUnauthorized) WithPayload(payload *models.Error) *GetImportUnauthorized {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get import unauthorized response
+func (o *GetImportUnauthorized) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetImportUnauthorized) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(401)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
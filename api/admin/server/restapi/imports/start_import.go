@@ -0,0 +1,71 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package imports
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// StartImportHandlerFunc turns a function with the right signature into a start import handler
+type StartImportHandlerFunc func(StartImportParams, *model.Principal) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn StartImportHandlerFunc) Handle(params StartImportParams, principal *model.Principal) middleware.Responder {
+	return fn(params, principal)
+}
+
+// StartImportHandler interface for that can handle valid start import params
+type StartImportHandler interface {
+	Handle(StartImportParams, *model.Principal) middleware.Responder
+}
+
+// NewStartImport creates a new http.Handler for the start import operation
+func NewStartImport(ctx *middleware.Context, handler StartImportHandler) *StartImport {
+	return &StartImport{Context: ctx, Handler: handler}
+}
+
+/* StartImport swagger:route POST /import imports startImport
+
+Start hibp data-import in the background with the database of serve, unless this instance already runs one.
+
+*/
+type StartImport struct {
+	Context *middleware.Context
+	Handler StartImportHandler
+}
+
+func (o *StartImport) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		r = rCtx
+	}
+	var Params = NewStartImportParams()
+	uprinc, aCtx, err := o.Context.Authorize(r, route)
+	if err != nil {
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+	if aCtx != nil {
+		r = aCtx
+	}
+	var principal *model.Principal
+	if uprinc != nil {
+		principal = uprinc.(*model.Principal) // this is really a model.Principal, I promise
+	}
+
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params, principal) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
@@ -0,0 +1,234 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package imports
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/leesalminen/hibp/api/admin/models"
+)
+
+// StartImportAcceptedCode is the HTTP code returned for type StartImportAccepted
+const StartImportAcceptedCode int = 202
+
+/*StartImportAccepted The import was started.
+
+swagger:response startImportAccepted
+*/
+type StartImportAccepted struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.ImportRun `json:"body,omitempty"`
+}
+
+// NewStartImportAccepted creates StartImportAccepted with default headers values
+func NewStartImportAccepted() *StartImportAccepted {
+
+	return &StartImportAccepted{}
+}
+
+// WithPayload adds the payload to the start import accepted response
+func (o *StartImportAccepted) WithPayload(payload *models.ImportRun) *StartImportAccepted {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the start import accepted response
+func (o *StartImportAccepted) SetPayload(payload *models.ImportRun) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *StartImportAccepted) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(202)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// StartImportBadRequestCode is the HTTP code returned for type StartImportBadRequest
+const StartImportBadRequestCode int = 400
+
+/*StartImportBadRequest The request failed.
+
+swagger:response startImportBadRequest
+*/
+type StartImportBadRequest struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewStartImportBadRequest creates StartImportBadRequest with default headers values
+func NewStartImportBadRequest() *StartImportBadRequest {
+
+	return &StartImportBadRequest{}
+}
+
+// WithPayload adds the payload to the start import bad request response
+func (o *StartImportBadRequest) WithPayload(payload *models.Error) *StartImportBadRequest {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the start import bad request response
+func (o *StartImportBadRequest) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *StartImportBadRequest) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(400)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// StartImportUnauthorizedCode is the HTTP code returned for type StartImportUnauthorized
+const StartImportUnauthorizedCode int = 401
+
+/*StartImportUnauthorized The admin token is missing or invalid.
+
+swagger:response startImportUnauthorized
+*/
+type StartImportUnauthorized struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewStartImportUnauthorized creates StartImportUnauthorized with default headers values
+func NewStartImportUnauthorized() *StartImportUnauthorized {
+
+	return &StartImportUnauthorized{}
+}
+
+// WithPayload adds the payload to the start import unauthorized response
+func (o *StartImportUnauthorized) WithPayload(payload *models.Error) *StartImportUnauthorized {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the start import unauthorized response
+func (o *StartImportUnauthorized) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *StartImportUnauthorized) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(401)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// StartImportConflictCode is the HTTP code returned for type StartImportConflict
+const StartImportConflictCode int = 409
+
+/*StartImportConflict An import is already running.
+
+swagger:response startImportConflict
+*/
+type StartImportConflict struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewStartImportConflict creates StartImportConflict with default headers values
+func NewStartImportConflict() *StartImportConflict {
+
+	return &StartImportConflict{}
+}
+
+// WithPayload adds the payload to the start import conflict response
+func (o *StartImportConflict) WithPayload(payload *models.Error) *StartImportConflict {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the start import conflict response
+func (o *StartImportConflict) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *StartImportConflict) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(409)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// StartImportInternalServerErrorCode is the HTTP code returned for type StartImportInternalServerError
+const StartImportInternalServerErrorCode int = 500
+
+/*StartImportInternalServerError The request failed.
+
+swagger:response startImportInternalServerError
+*/
+type StartImportInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewStartImportInternalServerError creates StartImportInternalServerError with default headers values
+func NewStartImportInternalServerError() *StartImportInternalServerError {
+
+	return &StartImportInternalServerError{}
+}
+
+// WithPayload adds the payload to the start import internal server error response
+func (o *StartImportInternalServerError) WithPayload(payload *models.Error) *StartImportInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the start import internal server error response
+func (o *StartImportInternalServerError) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *StartImportInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
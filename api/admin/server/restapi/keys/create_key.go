@@ -0,0 +1,71 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package keys
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// CreateKeyHandlerFunc turns a function with the right signature into a create key handler
+type CreateKeyHandlerFunc func(CreateKeyParams, *model.Principal) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn CreateKeyHandlerFunc) Handle(params CreateKeyParams, principal *model.Principal) middleware.Responder {
+	return fn(params, principal)
+}
+
+// CreateKeyHandler interface for that can handle valid create key params
+type CreateKeyHandler interface {
+	Handle(CreateKeyParams, *model.Principal) middleware.Responder
+}
+
+// NewCreateKey creates a new http.Handler for the create key operation
+func NewCreateKey(ctx *middleware.Context, handler CreateKeyHandler) *CreateKey {
+	return &CreateKey{Context: ctx, Handler: handler}
+}
+
+/* CreateKey swagger:route POST /keys keys createKey
+
+Create an API key. The key is only returned in this response.
+
+*/
+type CreateKey struct {
+	Context *middleware.Context
+	Handler CreateKeyHandler
+}
+
+func (o *CreateKey) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		r = rCtx
+	}
+	var Params = NewCreateKeyParams()
+	uprinc, aCtx, err := o.Context.Authorize(r, route)
+	if err != nil {
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+	if aCtx != nil {
+		r = aCtx
+	}
+	var principal *model.Principal
+	if uprinc != nil {
+		principal = uprinc.(*model.Principal) // this is really a model.Principal, I promise
+	}
+
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params, principal) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
@@ -0,0 +1,190 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package keys
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/leesalminen/hibp/api/admin/models"
+)
+
+// CreateKeyCreatedCode is the HTTP code returned for type CreateKeyCreated
+const CreateKeyCreatedCode int = 201
+
+/*CreateKeyCreated The key was created.
+
+swagger:response createKeyCreated
+*/
+type CreateKeyCreated struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.CreatedKey `json:"body,omitempty"`
+}
+
+// NewCreateKeyCreated creates CreateKeyCreated with default headers values
+func NewCreateKeyCreated() *CreateKeyCreated {
+
+	return &CreateKeyCreated{}
+}
+
+// WithPayload adds the payload to the create key created response
+func (o *CreateKeyCreated) WithPayload(payload *models.CreatedKey) *CreateKeyCreated {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the create key created response
+func (o *CreateKeyCreated) SetPayload(payload *models.CreatedKey) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *CreateKeyCreated) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(201)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// CreateKeyUnauthorizedCode is the HTTP code returned for type CreateKeyUnauthorized
+const CreateKeyUnauthorizedCode int = 401
+
+/*CreateKeyUnauthorized The admin token is missing or invalid.
+
+swagger:response createKeyUnauthorized
+*/
+type CreateKeyUnauthorized struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewCreateKeyUnauthorized creates CreateKeyUnauthorized with default headers values
+func NewCreateKeyUnauthorized() *CreateKeyUnauthorized {
+
+	return &CreateKeyUnauthorized{}
+}
+
+// WithPayload adds the payload to the create key unauthorized response
+func (o *CreateKeyUnauthorized) WithPayload(payload *models.Error) *CreateKeyUnauthorized {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the create key unauthorized response
+func (o *CreateKeyUnauthorized) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *CreateKeyUnauthorized) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(401)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// CreateKeyConflictCode is the HTTP code returned for type CreateKeyConflict
+const CreateKeyConflictCode int = 409
+
+/*CreateKeyConflict An active key with this name exists.
+
+swagger:response createKeyConflict
+*/
+type CreateKeyConflict struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewCreateKeyConflict creates CreateKeyConflict with default headers values
+func NewCreateKeyConflict() *CreateKeyConflict {
+
+	return &CreateKeyConflict{}
+}
+
+// WithPayload adds the payload to the create key conflict response
+func (o *CreateKeyConflict) WithPayload(payload *models.Error) *CreateKeyConflict {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the create key conflict response
+func (o *CreateKeyConflict) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *CreateKeyConflict) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(409)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// CreateKeyInternalServerErrorCode is the HTTP code returned for type CreateKeyInternalServerError
+const CreateKeyInternalServerErrorCode int = 500
+
+/*CreateKeyInternalServerError The request failed.
+
+swagger:response createKeyInternalServerError
+*/
+type CreateKeyInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewCreateKeyInternalServerError creates CreateKeyInternalServerError with default headers values
+func NewCreateKeyInternalServerError() *CreateKeyInternalServerError {
+
+	return &CreateKeyInternalServerError{}
+}
+
+// WithPayload adds the payload to the create key internal server error response
+func (o *CreateKeyInternalServerError) WithPayload(payload *models.Error) *CreateKeyInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the create key internal server error response
+func (o *CreateKeyInternalServerError) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *CreateKeyInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
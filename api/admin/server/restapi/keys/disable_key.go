@@ -0,0 +1,71 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package keys
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// DisableKeyHandlerFunc turns a function with the right signature into a disable key handler
+type DisableKeyHandlerFunc func(DisableKeyParams, *model.Principal) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn DisableKeyHandlerFunc) Handle(params DisableKeyParams, principal *model.Principal) middleware.Responder {
+	return fn(params, principal)
+}
+
+// DisableKeyHandler interface for that can handle valid disable key params
+type DisableKeyHandler interface {
+	Handle(DisableKeyParams, *model.Principal) middleware.Responder
+}
+
+// NewDisableKey creates a new http.Handler for the disable key operation
+func NewDisableKey(ctx *middleware.Context, handler DisableKeyHandler) *DisableKey {
+	return &DisableKey{Context: ctx, Handler: handler}
+}
+
+/* DisableKey swagger:route POST /keys/{name}/disable keys disableKey
+
+Disable an API key until it is enabled again.
+
+*/
+type DisableKey struct {
+	Context *middleware.Context
+	Handler DisableKeyHandler
+}
+
+func (o *DisableKey) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		r = rCtx
+	}
+	var Params = NewDisableKeyParams()
+	uprinc, aCtx, err := o.Context.Authorize(r, route)
+	if err != nil {
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+	if aCtx != nil {
+		r = aCtx
+	}
+	var principal *model.Principal
+	if uprinc != nil {
+		principal = uprinc.(*model.Principal) // this is really a model.Principal, I promise
+	}
+
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params, principal) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
@@ -0,0 +1,170 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package keys
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/leesalminen/hibp/api/admin/models"
+)
+
+// DisableKeyNoContentCode is the HTTP code returned for type DisableKeyNoContent
+const DisableKeyNoContentCode int = 204
+
+/*DisableKeyNoContent The key was disabled.
+
+swagger:response disableKeyNoContent
+*/
+type DisableKeyNoContent struct {
+}
+
+// NewDisableKeyNoContent creates DisableKeyNoContent with default headers values
+func NewDisableKeyNoContent() *DisableKeyNoContent {
+
+	return &DisableKeyNoContent{}
+}
+
+// WriteResponse to the client
+func (o *DisableKeyNoContent) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.Header().Del(runtime.HeaderContentType) //Remove Content-Type on empty responses
+
+	rw.WriteHeader(204)
+}
+
+// DisableKeyUnauthorizedCode is the HTTP code returned for type DisableKeyUnauthorized
+const DisableKeyUnauthorizedCode int = 401
+
+/*DisableKeyUnauthorized The admin token is missing or invalid.
+
+swagger:response disableKeyUnauthorized
+*/
+type DisableKeyUnauthorized struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewDisableKeyUnauthorized creates DisableKeyUnauthorized with default headers values
+func NewDisableKeyUnauthorized() *DisableKeyUnauthorized {
+
+	return &DisableKeyUnauthorized{}
+}
+
+// WithPayload adds the payload to the disable key unauthorized response
+func (o *DisableKeyUnauthorized) WithPayload(payload *models.Error) *DisableKeyUnauthorized {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the disable key unauthorized response
+func (o *DisableKeyUnauthorized) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *DisableKeyUnauthorized) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(401)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// DisableKeyNotFoundCode is the HTTP code returned for type DisableKeyNotFound
+const DisableKeyNotFoundCode int = 404
+
+/*DisableKeyNotFound No active key with this name exists.
+
+swagger:response disableKeyNotFound
+*/
+type DisableKeyNotFound struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewDisableKeyNotFound creates DisableKeyNotFound with default headers values
+func NewDisableKeyNotFound() *DisableKeyNotFound {
+
+	return &DisableKeyNotFound{}
+}
+
+// WithPayload adds the payload to the disable key not found response
+func (o *DisableKeyNotFound) WithPayload(payload *models.Error) *DisableKeyNotFound {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the disable key not found response
+func (o *DisableKeyNotFound) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *DisableKeyNotFound) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(404)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// DisableKeyInternalServerErrorCode is the HTTP code returned for type DisableKeyInternalServerError
+const DisableKeyInternalServerErrorCode int = 500
+
+/*DisableKeyInternalServerError The request failed.
+
+swagger:response disableKeyInternalServerError
+*/
+type DisableKeyInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewDisableKeyInternalServerError creates DisableKeyInternalServerError with default headers values
+func NewDisableKeyInternalServerError() *DisableKeyInternalServerError {
+
+	return &DisableKeyInternalServerError{}
+}
+
+// WithPayload adds the payload to the disable key internal server error response
+func (o *DisableKeyInternalServerError) WithPayload(payload *models.Error) *DisableKeyInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the disable key internal server error response
+func (o *DisableKeyInternalServerError) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *DisableKeyInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
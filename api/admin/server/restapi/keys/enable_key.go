@@ -0,0 +1,71 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package keys
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// EnableKeyHandlerFunc turns a function with the right signature into a enable key handler
+type EnableKeyHandlerFunc func(EnableKeyParams, *model.Principal) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn EnableKeyHandlerFunc) Handle(params EnableKeyParams, principal *model.Principal) middleware.Responder {
+	return fn(params, principal)
+}
+
+// EnableKeyHandler interface for that can handle valid enable key params
+type EnableKeyHandler interface {
+	Handle(EnableKeyParams, *model.Principal) middleware.Responder
+}
+
+// NewEnableKey creates a new http.Handler for the enable key operation
+func NewEnableKey(ctx *middleware.Context, handler EnableKeyHandler) *EnableKey {
+	return &EnableKey{Context: ctx, Handler: handler}
+}
+
+/* EnableKey swagger:route POST /keys/{name}/enable keys enableKey
+
+Enable a disabled API key.
+
+*/
+type EnableKey struct {
+	Context *middleware.Context
+	Handler EnableKeyHandler
+}
+
+func (o *EnableKey) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		r = rCtx
+	}
+	var Params = NewEnableKeyParams()
+	uprinc, aCtx, err := o.Context.Authorize(r, route)
+	if err != nil {
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+	if aCtx != nil {
+		r = aCtx
+	}
+	var principal *model.Principal
+	if uprinc != nil {
+		principal = uprinc.(*model.Principal) // this is really a model.Principal, I promise
+	}
+
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params, principal) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
@@ -0,0 +1,170 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package keys
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/leesalminen/hibp/api/admin/models"
+)
+
+// EnableKeyNoContentCode is the HTTP code returned for type EnableKeyNoContent
+const EnableKeyNoContentCode int = 204
+
+/*EnableKeyNoContent The key was enabled.
+
+swagger:response enableKeyNoContent
+*/
+type EnableKeyNoContent struct {
+}
+
+// NewEnableKeyNoContent creates EnableKeyNoContent with default headers values
+func NewEnableKeyNoContent() *EnableKeyNoContent {
+
+	return &EnableKeyNoContent{}
+}
+
+// WriteResponse to the client
+func (o *EnableKeyNoContent) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.Header().Del(runtime.HeaderContentType) //Remove Content-Type on empty responses
+
+	rw.WriteHeader(204)
+}
+
+// EnableKeyUnauthorizedCode is the HTTP code returned for type EnableKeyUnauthorized
+const EnableKeyUnauthorizedCode int = 401
+
+/*EnableKeyUnauthorized The admin token is missing or invalid.
+
+swagger:response enableKeyUnauthorized
+*/
+type EnableKeyUnauthorized struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewEnableKeyUnauthorized creates EnableKeyUnauthorized with default headers values
+func NewEnableKeyUnauthorized() *EnableKeyUnauthorized {
+
+	return &EnableKeyUnauthorized{}
+}
+
+// WithPayload adds the payload to the enable key unauthorized response
+func (o *EnableKeyUnauthorized) WithPayload(payload *models.Error) *EnableKeyUnauthorized {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the enable key unauthorized response
+func (o *EnableKeyUnauthorized) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *EnableKeyUnauthorized) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(401)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// EnableKeyNotFoundCode is the HTTP code returned for type EnableKeyNotFound
+const EnableKeyNotFoundCode int = 404
+
+/*EnableKeyNotFound No active key with this name exists.
+
+swagger:response enableKeyNotFound
+*/
+type EnableKeyNotFound struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewEnableKeyNotFound creates EnableKeyNotFound with default headers values
+func NewEnableKeyNotFound() *EnableKeyNotFound {
+
+	return &EnableKeyNotFound{}
+}
+
+// WithPayload adds the payload to the enable key not found response
+func (o *EnableKeyNotFound) WithPayload(payload *models.Error) *EnableKeyNotFound {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the enable key not found response
+func (o *EnableKeyNotFound) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *EnableKeyNotFound) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(404)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// EnableKeyInternalServerErrorCode is the HTTP code returned for type EnableKeyInternalServerError
+const EnableKeyInternalServerErrorCode int = 500
+
+/*EnableKeyInternalServerError The request failed.
+
+swagger:response enableKeyInternalServerError
+*/
+type EnableKeyInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewEnableKeyInternalServerError creates EnableKeyInternalServerError with default headers values
+func NewEnableKeyInternalServerError() *EnableKeyInternalServerError {
+
+	return &EnableKeyInternalServerError{}
+}
+
+// WithPayload adds the payload to the enable key internal server error response
+func (o *EnableKeyInternalServerError) WithPayload(payload *models.Error) *EnableKeyInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the enable key internal server error response
+func (o *EnableKeyInternalServerError) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *EnableKeyInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
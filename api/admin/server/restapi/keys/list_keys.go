@@ -0,0 +1,71 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package keys
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// ListKeysHandlerFunc turns a function with the right signature into a list keys handler
+type ListKeysHandlerFunc func(ListKeysParams, *model.Principal) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn ListKeysHandlerFunc) Handle(params ListKeysParams, principal *model.Principal) middleware.Responder {
+	return fn(params, principal)
+}
+
+// ListKeysHandler interface for that can handle valid list keys params
+type ListKeysHandler interface {
+	Handle(ListKeysParams, *model.Principal) middleware.Responder
+}
+
+// NewListKeys creates a new http.Handler for the list keys operation
+func NewListKeys(ctx *middleware.Context, handler ListKeysHandler) *ListKeys {
+	return &ListKeys{Context: ctx, Handler: handler}
+}
+
+/* ListKeys swagger:route GET /keys keys listKeys
+
+List API keys and their usage, including disabled and revoked keys.
+
+*/
+type ListKeys struct {
+	Context *middleware.Context
+	Handler ListKeysHandler
+}
+
+func (o *ListKeys) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		r = rCtx
+	}
+	var Params = NewListKeysParams()
+	uprinc, aCtx, err := o.Context.Authorize(r, route)
+	if err != nil {
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+	if aCtx != nil {
+		r = aCtx
+	}
+	var principal *model.Principal
+	if uprinc != nil {
+		principal = uprinc.(*model.Principal) // this is really a model.Principal, I promise
+	}
+
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params, principal) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
@@ -0,0 +1,149 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package keys
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/leesalminen/hibp/api/admin/models"
+)
+
+// ListKeysOKCode is the HTTP code returned for type ListKeysOK
+const ListKeysOKCode int = 200
+
+/*ListKeysOK API keys.
+
+swagger:response listKeysOK
+*/
+type ListKeysOK struct {
+
+	/*
+	  In: Body
+	*/
+	Payload []*models.APIKey `json:"body,omitempty"`
+}
+
+// NewListKeysOK creates ListKeysOK with default headers values
+func NewListKeysOK() *ListKeysOK {
+
+	return &ListKeysOK{}
+}
+
+// WithPayload adds the payload to the list keys o k response
+func (o *ListKeysOK) WithPayload(payload []*models.APIKey) *ListKeysOK {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the list keys o k response
+func (o *ListKeysOK) SetPayload(payload []*models.APIKey) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *ListKeysOK) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(200)
+	payload := o.Payload
+	if payload == nil {
+		// return empty array
+		payload = make([]*models.APIKey, 0, 50)
+	}
+
+	if err := producer.Produce(rw, payload); err != nil {
+		panic(err) // let the recovery middleware deal with this
+	}
+}
+
+// ListKeysUnauthorizedCode is the HTTP code returned for type ListKeysUnauthorized
+const ListKeysUnauthorizedCode int = 401
+
+/*ListKeysUnauthorized The admin token is missing or invalid.
+
+swagger:response listKeysUnauthorized
+*/
+type ListKeysUnauthorized struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewListKeysUnauthorized creates ListKeysUnauthorized with default headers values
+func NewListKeysUnauthorized() *ListKeysUnauthorized {
+
+	return &ListKeysUnauthorized{}
+}
+
+// WithPayload adds the payload to the list keys unauthorized response
+func (o *ListKeysUnauthorized) WithPayload(payload *models.Error) *ListKeysUnauthorized {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the list keys unauthorized response
+func (o *ListKeysUnauthorized) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *ListKeysUnauthorized) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(401)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// ListKeysInternalServerErrorCode is the HTTP code returned for type ListKeysInternalServerError
+const ListKeysInternalServerErrorCode int = 500
+
+/*ListKeysInternalServerError The request failed.
+
+swagger:response listKeysInternalServerError
+*/
+type ListKeysInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewListKeysInternalServerError creates ListKeysInternalServerError with default headers values
+func NewListKeysInternalServerError() *ListKeysInternalServerError {
+
+	return &ListKeysInternalServerError{}
+}
+
+// WithPayload adds the payload to the list keys internal server error response
+func (o *ListKeysInternalServerError) WithPayload(payload *models.Error) *ListKeysInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the list keys internal server error response
+func (o *ListKeysInternalServerError) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *ListKeysInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
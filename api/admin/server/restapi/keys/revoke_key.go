@@ -0,0 +1,71 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package keys
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// RevokeKeyHandlerFunc turns a function with the right signature into a revoke key handler
+type RevokeKeyHandlerFunc func(RevokeKeyParams, *model.Principal) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn RevokeKeyHandlerFunc) Handle(params RevokeKeyParams, principal *model.Principal) middleware.Responder {
+	return fn(params, principal)
+}
+
+// RevokeKeyHandler interface for that can handle valid revoke key params
+type RevokeKeyHandler interface {
+	Handle(RevokeKeyParams, *model.Principal) middleware.Responder
+}
+
+// NewRevokeKey creates a new http.Handler for the revoke key operation
+func NewRevokeKey(ctx *middleware.Context, handler RevokeKeyHandler) *RevokeKey {
+	return &RevokeKey{Context: ctx, Handler: handler}
+}
+
+/* RevokeKey swagger:route DELETE /keys/{name} keys revokeKey
+
+Permanently revoke an API key.
+
+*/
+type RevokeKey struct {
+	Context *middleware.Context
+	Handler RevokeKeyHandler
+}
+
+func (o *RevokeKey) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		r = rCtx
+	}
+	var Params = NewRevokeKeyParams()
+	uprinc, aCtx, err := o.Context.Authorize(r, route)
+	if err != nil {
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+	if aCtx != nil {
+		r = aCtx
+	}
+	var principal *model.Principal
+	if uprinc != nil {
+		principal = uprinc.(*model.Principal) // this is really a model.Principal, I promise
+	}
+
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params, principal) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
@@ -0,0 +1,170 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package keys
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/leesalminen/hibp/api/admin/models"
+)
+
+// RevokeKeyNoContentCode is the HTTP code returned for type RevokeKeyNoContent
+const RevokeKeyNoContentCode int = 204
+
+/*RevokeKeyNoContent The key was revoked.
+
+swagger:response revokeKeyNoContent
+*/
+type RevokeKeyNoContent struct {
+}
+
+// NewRevokeKeyNoContent creates RevokeKeyNoContent with default headers values
+func NewRevokeKeyNoContent() *RevokeKeyNoContent {
+
+	return &RevokeKeyNoContent{}
+}
+
+// WriteResponse to the client
+func (o *RevokeKeyNoContent) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.Header().Del(runtime.HeaderContentType) //Remove Content-Type on empty responses
+
+	rw.WriteHeader(204)
+}
+
+// RevokeKeyUnauthorizedCode is the HTTP code returned for type RevokeKeyUnauthorized
+const RevokeKeyUnauthorizedCode int = 401
+
+/*RevokeKeyUnauthorized The admin token is missing or invalid.
+
+swagger:response revokeKeyUnauthorized
+*/
+type RevokeKeyUnauthorized struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewRevokeKeyUnauthorized creates RevokeKeyUnauthorized with default headers values
+func NewRevokeKeyUnauthorized() *RevokeKeyUnauthorized {
+
+	return &RevokeKeyUnauthorized{}
+}
+
+// WithPayload adds the payload to the revoke key unauthorized response
+func (o *RevokeKeyUnauthorized) WithPayload(payload *models.Error) *RevokeKeyUnauthorized {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the revoke key unauthorized response
+func (o *RevokeKeyUnauthorized) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *RevokeKeyUnauthorized) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(401)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// RevokeKeyNotFoundCode is the HTTP code returned for type RevokeKeyNotFound
+const RevokeKeyNotFoundCode int = 404
+
+/*RevokeKeyNotFound No active key with this name exists.
+
+swagger:response revokeKeyNotFound
+*/
+type RevokeKeyNotFound struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewRevokeKeyNotFound creates RevokeKeyNotFound with default headers values
+func NewRevokeKeyNotFound() *RevokeKeyNotFound {
+
+	return &RevokeKeyNotFound{}
+}
+
+// WithPayload adds the payload to the revoke key not found response
+func (o *RevokeKeyNotFound) WithPayload(payload *models.Error) *RevokeKeyNotFound {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the revoke key not found response
+func (o *RevokeKeyNotFound) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *RevokeKeyNotFound) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(404)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// RevokeKeyInternalServerErrorCode is the HTTP code returned for type RevokeKeyInternalServerError
+const RevokeKeyInternalServerErrorCode int = 500
+
+/*RevokeKeyInternalServerError The request failed.
+
+swagger:response revokeKeyInternalServerError
+*/
+type RevokeKeyInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewRevokeKeyInternalServerError creates RevokeKeyInternalServerError with default headers values
+func NewRevokeKeyInternalServerError() *RevokeKeyInternalServerError {
+
+	return &RevokeKeyInternalServerError{}
+}
+
+// WithPayload adds the payload to the revoke key internal server error response
+func (o *RevokeKeyInternalServerError) WithPayload(payload *models.Error) *RevokeKeyInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the revoke key internal server error response
+func (o *RevokeKeyInternalServerError) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *RevokeKeyInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
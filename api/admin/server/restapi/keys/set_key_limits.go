@@ -0,0 +1,71 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package keys
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// SetKeyLimitsHandlerFunc turns a function with the right signature into a set key limits handler
+type SetKeyLimitsHandlerFunc func(SetKeyLimitsParams, *model.Principal) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn SetKeyLimitsHandlerFunc) Handle(params SetKeyLimitsParams, principal *model.Principal) middleware.Responder {
+	return fn(params, principal)
+}
+
+// SetKeyLimitsHandler interface for that can handle valid set key limits params
+type SetKeyLimitsHandler interface {
+	Handle(SetKeyLimitsParams, *model.Principal) middleware.Responder
+}
+
+// NewSetKeyLimits creates a new http.Handler for the set key limits operation
+func NewSetKeyLimits(ctx *middleware.Context, handler SetKeyLimitsHandler) *SetKeyLimits {
+	return &SetKeyLimits{Context: ctx, Handler: handler}
+}
+
+/* SetKeyLimits swagger:route PUT /keys/{name}/limits keys setKeyLimits
+
+Replace the rate limit and daily quota of an API key. Zero values use the defaults of serve.
+
+*/
+type SetKeyLimits struct {
+	Context *middleware.Context
+	Handler SetKeyLimitsHandler
+}
+
+func (o *SetKeyLimits) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		r = rCtx
+	}
+	var Params = NewSetKeyLimitsParams()
+	uprinc, aCtx, err := o.Context.Authorize(r, route)
+	if err != nil {
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+	if aCtx != nil {
+		r = aCtx
+	}
+	var principal *model.Principal
+	if uprinc != nil {
+		principal = uprinc.(*model.Principal) // this is really a model.Principal, I promise
+	}
+
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params, principal) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
@@ -0,0 +1,170 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package keys
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/leesalminen/hibp/api/admin/models"
+)
+
+// SetKeyLimitsNoContentCode is the HTTP code returned for type SetKeyLimitsNoContent
+const SetKeyLimitsNoContentCode int = 204
+
+/*SetKeyLimitsNoContent The limits were replaced.
+
+swagger:response setKeyLimitsNoContent
+*/
+type SetKeyLimitsNoContent struct {
+}
+
+// NewSetKeyLimitsNoContent creates SetKeyLimitsNoContent with default headers values
+func NewSetKeyLimitsNoContent() *SetKeyLimitsNoContent {
+
+	return &SetKeyLimitsNoContent{}
+}
+
+// WriteResponse to the client
+func (o *SetKeyLimitsNoContent) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.Header().Del(runtime.HeaderContentType) //Remove Content-Type on empty responses
+
+	rw.WriteHeader(204)
+}
+
+// SetKeyLimitsUnauthorizedCode is the HTTP code returned for type SetKeyLimitsUnauthorized
+const SetKeyLimitsUnauthorizedCode int = 401
+
+/*SetKeyLimitsUnauthorized The admin token is missing or invalid.
+
+swagger:response setKeyLimitsUnauthorized
+*/
+type SetKeyLimitsUnauthorized struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewSetKeyLimitsUnauthorized creates SetKeyLimitsUnauthorized with default headers values
+func NewSetKeyLimitsUnauthorized() *SetKeyLimitsUnauthorized {
+
+	return &SetKeyLimitsUnauthorized{}
+}
+
+// WithPayload adds the payload to the set key limits unauthorized response
+func (o *SetKeyLimitsUnauthorized) WithPayload(payload *models.Error) *SetKeyLimitsUnauthorized {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the set key limits unauthorized response
+func (o *SetKeyLimitsUnauthorized) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *SetKeyLimitsUnauthorized) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(401)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// SetKeyLimitsNotFoundCode is the HTTP code returned for type SetKeyLimitsNotFound
+const SetKeyLimitsNotFoundCode int = 404
+
+/*SetKeyLimitsNotFound No active key with this name exists.
+
+swagger:response setKeyLimitsNotFound
+*/
+type SetKeyLimitsNotFound struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewSetKeyLimitsNotFound creates SetKeyLimitsNotFound with default headers values
+func NewSetKeyLimitsNotFound() *SetKeyLimitsNotFound {
+
+	return &SetKeyLimitsNotFound{}
+}
+
+// WithPayload adds the payload to the set key limits not found response
+func (o *SetKeyLimitsNotFound) WithPayload(payload *models.Error) *SetKeyLimitsNotFound {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the set key limits not found response
+func (o *SetKeyLimitsNotFound) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *SetKeyLimitsNotFound) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(404)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// SetKeyLimitsInternalServerErrorCode is the HTTP code returned for type SetKeyLimitsInternalServerError
+const SetKeyLimitsInternalServerErrorCode int = 500
+
+/*SetKeyLimitsInternalServerError The request failed.
+
+swagger:response setKeyLimitsInternalServerError
+*/
+type SetKeyLimitsInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewSetKeyLimitsInternalServerError creates SetKeyLimitsInternalServerError with default headers values
+func NewSetKeyLimitsInternalServerError() *SetKeyLimitsInternalServerError {
+
+	return &SetKeyLimitsInternalServerError{}
+}
+
+// WithPayload adds the payload to the set key limits internal server error response
+func (o *SetKeyLimitsInternalServerError) WithPayload(payload *models.Error) *SetKeyLimitsInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the set key limits internal server error response
+func (o *SetKeyLimitsInternalServerError) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *SetKeyLimitsInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
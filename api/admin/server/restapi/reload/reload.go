@@ -0,0 +1,71 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package reload
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// ReloadHandlerFunc turns a function with the right signature into a reload handler
+type ReloadHandlerFunc func(ReloadParams, *model.Principal) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn ReloadHandlerFunc) Handle(params ReloadParams, principal *model.Principal) middleware.Responder {
+	return fn(params, principal)
+}
+
+// ReloadHandler interface for that can handle valid reload params
+type ReloadHandler interface {
+	Handle(ReloadParams, *model.Principal) middleware.Responder
+}
+
+// NewReload creates a new http.Handler for the reload operation
+func NewReload(ctx *middleware.Context, handler ReloadHandler) *Reload {
+	return &Reload{Context: ctx, Handler: handler}
+}
+
+/* Reload swagger:route POST /reload reload reload
+
+Reload the TLS certificate and the CRL files and drop all cached API keys.
+
+*/
+type Reload struct {
+	Context *middleware.Context
+	Handler ReloadHandler
+}
+
+func (o *Reload) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		r = rCtx
+	}
+	var Params = NewReloadParams()
+	uprinc, aCtx, err := o.Context.Authorize(r, route)
+	if err != nil {
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+	if aCtx != nil {
+		r = aCtx
+	}
+	var principal *model.Principal
+	if uprinc != nil {
+		principal = uprinc.(*model.Principal) // this is really a model.Principal, I promise
+	}
+
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params, principal) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
@@ -0,0 +1,126 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package reload
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/leesalminen/hibp/api/admin/models"
+)
+
+// ReloadNoContentCode is the HTTP code returned for type ReloadNoContent
+const ReloadNoContentCode int = 204
+
+/*ReloadNoContent The server was reloaded.
+
+swagger:response reloadNoContent
+*/
+type ReloadNoContent struct {
+}
+
+// NewReloadNoContent creates ReloadNoContent with default headers values
+func NewReloadNoContent() *ReloadNoContent {
+
+	return &ReloadNoContent{}
+}
+
+// WriteResponse to the client
+func (o *ReloadNoContent) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.Header().Del(runtime.HeaderContentType) //Remove Content-Type on empty responses
+
+	rw.WriteHeader(204)
+}
+
+// ReloadUnauthorizedCode is the HTTP code returned for type ReloadUnauthorized
+const ReloadUnauthorizedCode int = 401
+
+/*ReloadUnauthorized The admin token is missing or invalid.
+
+swagger:response reloadUnauthorized
+*/
+type ReloadUnauthorized struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewReloadUnauthorized creates ReloadUnauthorized with default headers values
+func NewReloadUnauthorized() *ReloadUnauthorized {
+
+	return &ReloadUnauthorized{}
+}
+
+// WithPayload adds the payload to the reload unauthorized response
+func (o *ReloadUnauthorized) WithPayload(payload *models.Error) *ReloadUnauthorized {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the reload unauthorized response
+func (o *ReloadUnauthorized) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *ReloadUnauthorized) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(401)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// ReloadInternalServerErrorCode is the HTTP code returned for type ReloadInternalServerError
+const ReloadInternalServerErrorCode int = 500
+
+/*ReloadInternalServerError The request failed.
+
+swagger:response reloadInternalServerError
+*/
+type ReloadInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewReloadInternalServerError creates ReloadInternalServerError with default headers values
+func NewReloadInternalServerError() *ReloadInternalServerError {
+
+	return &ReloadInternalServerError{}
+}
+
+// WithPayload adds the payload to the reload internal server error response
+func (o *ReloadInternalServerError) WithPayload(payload *models.Error) *ReloadInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the reload internal server error response
+func (o *ReloadInternalServerError) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *ReloadInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package status
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// GetStatusHandlerFunc turns a function with the right signature into a get status handler
+type GetStatusHandlerFunc func(GetStatusParams, *model.Principal) middleware.Responder
+
+// Handle executing the request and returning a response
+func (fn GetStatusHandlerFunc) Handle(params GetStatusParams, principal *model.Principal) middleware.Responder {
+	return fn(params, principal)
+}
+
+// GetStatusHandler interface for that can handle valid get status params
+type GetStatusHandler interface {
+	Handle(GetStatusParams, *model.Principal) middleware.Responder
+}
+
+// NewGetStatus creates a new http.Handler for the get status operation
+func NewGetStatus(ctx *middleware.Context, handler GetStatusHandler) *GetStatus {
+	return &GetStatus{Context: ctx, Handler: handler}
+}
+
+/* GetStatus swagger:route GET /status status getStatus
+
+Imported dataset versions, table sizes, the scheduled refresh and the state of the last import started by this instance.
+
+*/
+type GetStatus struct {
+	Context *middleware.Context
+	Handler GetStatusHandler
+}
+
+func (o *GetStatus) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route, rCtx, _ := o.Context.RouteInfo(r)
+	if rCtx != nil {
+		r = rCtx
+	}
+	var Params = NewGetStatusParams()
+	uprinc, aCtx, err := o.Context.Authorize(r, route)
+	if err != nil {
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+	if aCtx != nil {
+		r = aCtx
+	}
+	var principal *model.Principal
+	if uprinc != nil {
+		principal = uprinc.(*model.Principal) // this is really a model.Principal, I promise
+	}
+
+	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+
+	res := o.Handler.Handle(Params, principal) // actually handle the request
+	o.Context.Respond(rw, r, route.Produces, route, res)
+
+}
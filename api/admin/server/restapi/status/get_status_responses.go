@@ -0,0 +1,146 @@
+// Code generated by go-swagger; DO NOT EDIT.
+
+package status
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime"
+
+	"github.com/leesalminen/hibp/api/admin/models"
+)
+
+// GetStatusOKCode is the HTTP code returned for type GetStatusOK
+const GetStatusOKCode int = 200
+
+/*GetStatusOK Status of the dataset.
+
+swagger:response getStatusOK
+*/
+type GetStatusOK struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Status `json:"body,omitempty"`
+}
+
+// NewGetStatusOK creates GetStatusOK with default headers values
+func NewGetStatusOK() *GetStatusOK {
+
+	return &GetStatusOK{}
+}
+
+// WithPayload adds the payload to the get status o k response
+func (o *GetStatusOK) WithPayload(payload *models.Status) *GetStatusOK {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get status o k response
+func (o *GetStatusOK) SetPayload(payload *models.Status) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetStatusOK) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(200)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// GetStatusUnauthorizedCode is the HTTP code returned for type GetStatusUnauthorized
+const GetStatusUnauthorizedCode int = 401
+
+/*GetStatusUnauthorized The admin token is missing or invalid.
+
+swagger:response getStatusUnauthorized
+*/
+type GetStatusUnauthorized struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewGetStatusUnauthorized creates GetStatusUnauthorized with default headers values
+func NewGetStatusUnauthorized() *GetStatusUnauthorized {
+
+	return &GetStatusUnauthorized{}
+}
+
+// WithPayload adds the payload to the get status unauthorized response
+func (o *GetStatusUnauthorized) WithPayload(payload *models.Error) *GetStatusUnauthorized {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get status unauthorized response
+func (o *GetStatusUnauthorized) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetStatusUnauthorized) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(401)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
+
+// GetStatusInternalServerErrorCode is the HTTP code returned for type GetStatusInternalServerError
+const GetStatusInternalServerErrorCode int = 500
+
+/*GetStatusInternalServerError The request failed.
+
+swagger:response getStatusInternalServerError
+*/
+type GetStatusInternalServerError struct {
+
+	/*
+	  In: Body
+	*/
+	Payload *models.Error `json:"body,omitempty"`
+}
+
+// NewGetStatusInternalServerError creates GetStatusInternalServerError with default headers values
+func NewGetStatusInternalServerError() *GetStatusInternalServerError {
+
+	return &GetStatusInternalServerError{}
+}
+
+// WithPayload adds the payload to the get status internal server error response
+func (o *GetStatusInternalServerError) WithPayload(payload *models.Error) *GetStatusInternalServerError {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the get status internal server error response
+func (o *GetStatusInternalServerError) SetPayload(payload *models.Error) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *GetStatusInternalServerError) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(500)
+	if o.Payload != nil {
+		payload := o.Payload
+		if err := producer.Produce(rw, payload); err != nil {
+			panic(err) // let the recovery middleware deal with this
+		}
+	}
+}
@@ -12,9 +12,10 @@ import (
 
 	"github.com/leesalminen/hibp/api/server/restapi"
 	"github.com/leesalminen/hibp/api/server/restapi/range_restapi"
+	"github.com/leesalminen/hibp/model"
 )
 
-//go:generate swagger generate server --target ../../api --name SelfHostedHIBPPasswordHashChecker --spec ../../.swagger/api.swagger.yaml --api-package restapi --server-package server --principal interface{} --exclude-main
+//go:generate swagger generate server --target ../../api --name SelfHostedHIBPPasswordHashChecker --spec ../../.swagger/api.swagger.yaml --api-package restapi --server-package server --principal github.com/leesalminen/hibp/model.Principal --exclude-main
 
 func configureFlags(api *restapi.SelfHostedHIBPPasswordHashCheckerAPI) {
 	// api.CommandLineOptionsGroups = []swag.CommandLineOptionsGroup{ ... }
@@ -36,10 +37,29 @@ func configureAPI(api *restapi.SelfHostedHIBPPasswordHashCheckerAPI) http.Handle
 
 	api.JSONConsumer = runtime.JSONConsumer()
 
+	// Applies when the "hibp-api-key" header is set
+	if api.APIKeyAuth == nil {
+		api.APIKeyAuth = func(token string) (*model.Principal, error) {
+			return nil, errors.NotImplemented("api key auth (api_key) hibp-api-key from header param [hibp-api-key] has not yet been implemented")
+		}
+	}
+
+	if api.BearerAuth == nil {
+		api.BearerAuth = func(token string, scopes []string) (*model.Principal, error) {
+			return nil, errors.NotImplemented("oauth2 bearer auth (bearer) has not yet been implemented")
+		}
+	}
+
+	// Set your custom authorizer if needed. Default one is security.Authorized()
+	// Expected interface runtime.Authorizer
+	//
+	// Example:
+	// api.APIAuthorizer = security.Authorized()
+
 	api.TxtProducer = runtime.TextProducer()
 
 	if api.RangeRestapiRangeSearchHandler == nil {
-		api.RangeRestapiRangeSearchHandler = range_restapi.RangeSearchHandlerFunc(func(params range_restapi.RangeSearchParams) middleware.Responder {
+		api.RangeRestapiRangeSearchHandler = range_restapi.RangeSearchHandlerFunc(func(params range_restapi.RangeSearchParams, principal *model.Principal) middleware.Responder {
 			return middleware.NotImplemented("operation range_restapi.RangeSearch has not yet been implemented")
 		})
 	}
@@ -51,9 +71,16 @@ func configureAPI(api *restapi.SelfHostedHIBPPasswordHashCheckerAPI) http.Handle
 	return setupGlobalMiddleware(api.Serve(setupMiddlewares))
 }
 
+// ConfigureTLS is called with the TLS configuration before the HTTPS server
+// starts, after the certificate and client CA flags are applied.
+var ConfigureTLS func(*tls.Config)
+
 // The TLS configuration before HTTPS server starts.
 func configureTLS(tlsConfig *tls.Config) {
 	// Make all necessary changes to the TLS configuration here.
+	if ConfigureTLS != nil {
+		ConfigureTLS(tlsConfig)
+	}
 }
 
 // As soon as server is initialized but not run yet, this function will be called.
@@ -50,6 +50,15 @@ func init() {
           "range"
         ],
         "operationId": "rangeSearch",
+        "security": [
+          {
+            "api_key": []
+          },
+          {
+            "bearer": []
+          },
+          {}
+        ],
         "parameters": [
           {
             "type": "string",
@@ -66,7 +75,13 @@ func init() {
             }
           },
           "400": {
-            "description": "Hash prefix must be exactly 5 characters long.",
+            "description": "The hash prefix was not in a valid format.",
+            "schema": {
+              "type": "string"
+            }
+          },
+          "401": {
+            "description": "The API key or bearer token is missing or invalid.",
             "schema": {
               "type": "string"
             }
@@ -83,6 +98,20 @@ func init() {
         }
       }
     }
+  },
+  "securityDefinitions": {
+    "api_key": {
+      "description": "API key issued with hibp apikey create. Required unless serve allows anonymous requests.",
+      "type": "apiKey",
+      "name": "hibp-api-key",
+      "in": "header"
+    },
+    "bearer": {
+      "description": "JWT bearer token of the configured OIDC provider. Accepted when serve runs with --jwt-jwks-url or --jwt-key-file.",
+      "type": "oauth2",
+      "flow": "application",
+      "tokenUrl": "https://your-identity-provider.example.com/oauth2/token"
+    }
   }
 }`))
 	FlatSwaggerJSON = json.RawMessage([]byte(`{
@@ -118,6 +147,15 @@ func init() {
           "range"
         ],
         "operationId": "rangeSearch",
+        "security": [
+          {
+            "api_key": []
+          },
+          {
+            "bearer": []
+          },
+          {}
+        ],
         "parameters": [
           {
             "type": "string",
@@ -134,7 +172,13 @@ func init() {
             }
           },
           "400": {
-            "description": "Hash prefix must be exactly 5 characters long.",
+            "description": "The hash prefix was not in a valid format.",
+            "schema": {
+              "type": "string"
+            }
+          },
+          "401": {
+            "description": "The API key or bearer token is missing or invalid.",
             "schema": {
               "type": "string"
             }
@@ -151,6 +195,20 @@ func init() {
         }
       }
     }
+  },
+  "securityDefinitions": {
+    "api_key": {
+      "description": "API key issued with hibp apikey create. Required unless serve allows anonymous requests.",
+      "type": "apiKey",
+      "name": "hibp-api-key",
+      "in": "header"
+    },
+    "bearer": {
+      "description": "JWT bearer token of the configured OIDC provider. Accepted when serve runs with --jwt-jwks-url or --jwt-key-file.",
+      "type": "oauth2",
+      "flow": "application",
+      "tokenUrl": "https://your-identity-provider.example.com/oauth2/token"
+    }
   }
 }`))
 }
@@ -9,19 +9,21 @@ import (
 	"net/http"
 
 	"github.com/go-openapi/runtime/middleware"
+
+	"github.com/leesalminen/hibp/model"
 )
 
 // RangeSearchHandlerFunc turns a function with the right signature into a range search handler
-type RangeSearchHandlerFunc func(RangeSearchParams) middleware.Responder
+type RangeSearchHandlerFunc func(RangeSearchParams, *model.Principal) middleware.Responder
 
 // Handle executing the request and returning a response
-func (fn RangeSearchHandlerFunc) Handle(params RangeSearchParams) middleware.Responder {
-	return fn(params)
+func (fn RangeSearchHandlerFunc) Handle(params RangeSearchParams, principal *model.Principal) middleware.Responder {
+	return fn(params, principal)
 }
 
 // RangeSearchHandler interface for that can handle valid range search params
 type RangeSearchHandler interface {
-	Handle(RangeSearchParams) middleware.Responder
+	Handle(RangeSearchParams, *model.Principal) middleware.Responder
 }
 
 // NewRangeSearch creates a new http.Handler for the range search operation
@@ -45,12 +47,25 @@ func (o *RangeSearch) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		r = rCtx
 	}
 	var Params = NewRangeSearchParams()
+	uprinc, aCtx, err := o.Context.Authorize(r, route)
+	if err != nil {
+		o.Context.Respond(rw, r, route.Produces, route, err)
+		return
+	}
+	if aCtx != nil {
+		r = aCtx
+	}
+	var principal *model.Principal
+	if uprinc != nil {
+		principal = uprinc.(*model.Principal) // this is really a model.Principal, I promise
+	}
+
 	if err := o.Context.BindValidRequest(r, route, &Params); err != nil { // bind params
 		o.Context.Respond(rw, r, route.Produces, route, err)
 		return
 	}
 
-	res := o.Handler.Handle(Params) // actually handle the request
+	res := o.Handler.Handle(Params, principal) // actually handle the request
 	o.Context.Respond(rw, r, route.Produces, route, res)
 
 }
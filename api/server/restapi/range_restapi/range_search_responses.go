@@ -56,7 +56,7 @@ func (o *RangeSearchOK) WriteResponse(rw http.ResponseWriter, producer runtime.P
 // RangeSearchBadRequestCode is the HTTP code returned for type RangeSearchBadRequest
 const RangeSearchBadRequestCode int = 400
 
-/*RangeSearchBadRequest Hash prefix must be exactly 5 characters long.
+/*RangeSearchBadRequest The hash prefix was not in a valid format.
 
 swagger:response rangeSearchBadRequest
 */
@@ -95,6 +95,48 @@ func (o *RangeSearchBadRequest) WriteResponse(rw http.ResponseWriter, producer r
 	}
 }
 
+// RangeSearchUnauthorizedCode is the HTTP code returned for type RangeSearchUnauthorized
+const RangeSearchUnauthorizedCode int = 401
+
+/*RangeSearchUnauthorized The API key or bearer token is missing or invalid.
+
+swagger:response rangeSearchUnauthorized
+*/
+type RangeSearchUnauthorized struct {
+
+	/*
+	  In: Body
+	*/
+	Payload string `json:"body,omitempty"`
+}
+
+// NewRangeSearchUnauthorized creates RangeSearchUnauthorized with default headers values
+func NewRangeSearchUnauthorized() *RangeSearchUnauthorized {
+
+	return &RangeSearchUnauthorized{}
+}
+
+// WithPayload adds the payload to the range search unauthorized response
+func (o *RangeSearchUnauthorized) WithPayload(payload string) *RangeSearchUnauthorized {
+	o.Payload = payload
+	return o
+}
+
+// SetPayload sets the payload to the range search unauthorized response
+func (o *RangeSearchUnauthorized) SetPayload(payload string) {
+	o.Payload = payload
+}
+
+// WriteResponse to the client
+func (o *RangeSearchUnauthorized) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+
+	rw.WriteHeader(401)
+	payload := o.Payload
+	if err := producer.Produce(rw, payload); err != nil {
+		panic(err) // let the recovery middleware deal with this
+	}
+}
+
 // RangeSearchNotFoundCode is the HTTP code returned for type RangeSearchNotFound
 const RangeSearchNotFoundCode int = 404
 
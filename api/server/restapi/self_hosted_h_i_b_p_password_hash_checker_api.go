@@ -20,6 +20,7 @@ import (
 	"github.com/go-openapi/swag"
 
 	"github.com/leesalminen/hibp/api/server/restapi/range_restapi"
+	"github.com/leesalminen/hibp/model"
 )
 
 // NewSelfHostedHIBPPasswordHashCheckerAPI creates a new SelfHostedHIBPPasswordHashChecker instance
@@ -44,9 +45,19 @@ func NewSelfHostedHIBPPasswordHashCheckerAPI(spec *loads.Document) *SelfHostedHI
 
 		TxtProducer: runtime.TextProducer(),
 
-		RangeRestapiRangeSearchHandler: range_restapi.RangeSearchHandlerFunc(func(params range_restapi.RangeSearchParams) middleware.Responder {
+		RangeRestapiRangeSearchHandler: range_restapi.RangeSearchHandlerFunc(func(params range_restapi.RangeSearchParams, principal *model.Principal) middleware.Responder {
 			return middleware.NotImplemented("operation range_restapi.RangeSearch has not yet been implemented")
 		}),
+
+		// Applies when the "hibp-api-key" header is set
+		APIKeyAuth: func(token string) (*model.Principal, error) {
+			return nil, errors.NotImplemented("api key auth (api_key) hibp-api-key from header param [hibp-api-key] has not yet been implemented")
+		},
+		BearerAuth: func(token string, scopes []string) (*model.Principal, error) {
+			return nil, errors.NotImplemented("oauth2 bearer auth (bearer) has not yet been implemented")
+		},
+		// default authorizer is authorized meaning no requests are blocked
+		APIAuthorizer: security.Authorized(),
 	}
 }
 
@@ -75,6 +86,17 @@ type SelfHostedHIBPPasswordHashCheckerAPI struct {
 	// It has a default implementation in the security package, however you can replace it for your particular usage.
 	BearerAuthenticator func(string, security.ScopedTokenAuthentication) runtime.Authenticator
 
+	// APIKeyAuth registers a function that takes a token and returns a principal
+	// it performs authentication based on an api key hibp-api-key provided in the header
+	APIKeyAuth func(string) (*model.Principal, error)
+
+	// BearerAuth registers a function that takes an access token and a collection of required scopes and returns a principal
+	// it performs authentication based on an oauth2 bearer token provided in the request
+	BearerAuth func(string, []string) (*model.Principal, error)
+
+	// APIAuthorizer provides access control (ACL/RBAC/ABAC) by providing access to the request and authenticated principal
+	APIAuthorizer runtime.Authorizer
+
 	// JSONConsumer registers a consumer for the following mime types:
 	//   - application/json
 	JSONConsumer runtime.Consumer
@@ -162,6 +184,14 @@ func (o *SelfHostedHIBPPasswordHashCheckerAPI) Validate() error {
 		unregistered = append(unregistered, "TxtProducer")
 	}
 
+	if o.APIKeyAuth == nil {
+		unregistered = append(unregistered, "HibpAPIKeyAuth")
+	}
+
+	if o.BearerAuth == nil {
+		unregistered = append(unregistered, "BearerAuth")
+	}
+
 	if o.RangeRestapiRangeSearchHandler == nil {
 		unregistered = append(unregistered, "range_restapi.RangeSearchHandler")
 	}
@@ -180,12 +210,28 @@ func (o *SelfHostedHIBPPasswordHashCheckerAPI) ServeErrorFor(operationID string)
 
 // AuthenticatorsFor gets the authenticators for the specified security schemes
 func (o *SelfHostedHIBPPasswordHashCheckerAPI) AuthenticatorsFor(schemes map[string]spec.SecurityScheme) map[string]runtime.Authenticator {
-	return nil
+	result := make(map[string]runtime.Authenticator)
+	for name := range schemes {
+		switch name {
+		case "api_key":
+			scheme := schemes[name]
+			result[name] = o.APIKeyAuthenticator(scheme.Name, scheme.In, func(token string) (interface{}, error) {
+				return o.APIKeyAuth(token)
+			})
+
+		case "bearer":
+			result[name] = o.BearerAuthenticator(name, func(token string, scopes []string) (interface{}, error) {
+				return o.BearerAuth(token, scopes)
+			})
+
+		}
+	}
+	return result
 }
 
 // Authorizer returns the registered authorizer
 func (o *SelfHostedHIBPPasswordHashCheckerAPI) Authorizer() runtime.Authorizer {
-	return nil
+	return o.APIAuthorizer
 }
 
 // ConsumersFor gets the consumers for the specified media types.
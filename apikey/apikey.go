@@ -0,0 +1,324 @@
+// Package apikey issues and verifies the API keys clients send
+// in the hibp-api-key header.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/model"
+	"github.com/lib/pq"
+)
+
+// Header is the request header carrying the API key, as used by the upstream API.
+const Header = "hibp-api-key"
+
+// MethodAPIKey is the principal method of API key authentication.
+const MethodAPIKey = "api-key"
+
+// keyPrefix makes keys recognizable, e.g. in secret scanners.
+const keyPrefix = "hibp_"
+
+// ErrInvalidKey is returned for unknown, disabled and revoked keys.
+var ErrInvalidKey = errors.New("invalid API key")
+
+// ErrNotFound is returned when no active key has the given name.
+var ErrNotFound = errors.New("no active key")
+
+// ErrNameTaken is returned when an active key already has the given name.
+var ErrNameTaken = errors.New("an active key with this name exists")
+
+// invalidKeyTTL is how long an unknown, disabled or revoked key is
+// rejected without a query, so that random keys cannot load the database.
+const invalidKeyTTL = time.Minute
+
+// maxInvalidKeys bounds the number of cached invalid keys.
+const maxInvalidKeys = 10000
+
+// uniqueViolation is the Postgres error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// Generate returns a new random key and its hash.
+func Generate() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key := keyPrefix + hex.EncodeToString(buf)
+	return key, Hash(key), nil
+}
+
+// Hash returns the hex encoded SHA-256 hash a key is stored as.
+// Keys are random, so a fast hash is sufficient.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Limits of a key. Zero values use the defaults of serve, a zero DailyQuota means no quota.
+type Limits struct {
+	// Rate is the number of requests per second.
+	Rate float64
+	// Burst is the number of requests allowed at once.
+	Burst int
+	// DailyQuota is the number of requests per UTC day.
+	DailyQuota int64
+}
+
+// Create stores a new enabled key under name with limits in schema and returns the key.
+// The key can't be recovered later.
+func Create(ctx context.Context, db *sqlx.DB, schema, name string, limits Limits) (string, error) {
+	key, hash, err := Generate()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = db.ExecContext(ctx, `
+		insert into `+schema+`.hibp_api_key ("name", "key_hash", "rate_limit", "rate_burst", "daily_quota")
+		values ($1, $2, nullif($3::double precision, 0), nullif($4::integer, 0), nullif($5::bigint, 0))`,
+		name, hash, limits.Rate, limits.Burst, limits.DailyQuota)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return "", ErrNameTaken
+	}
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// SetLimits replaces the limits of the key name in schema.
+func SetLimits(ctx context.Context, db *sqlx.DB, schema, name string, limits Limits) error {
+	return updateKey(ctx, db, name, `
+		update `+schema+`.hibp_api_key
+		set "rate_limit" = nullif($2::double precision, 0), "rate_burst" = nullif($3::integer, 0), "daily_quota" = nullif($4::bigint, 0)
+		where "name" = $1 and "revoked_at" is null`,
+		limits.Rate, limits.Burst, limits.DailyQuota)
+}
+
+// List returns all keys of schema, including disabled and revoked ones.
+func List(ctx context.Context, db *sqlx.DB, schema string) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := db.SelectContext(ctx, &keys, `
+		select "key_id", "name", "key_hash", "enabled", "rate_limit", "rate_burst", "daily_quota",
+			"request_count", "last_used_at", "revoked_at", "created_at"
+		from `+schema+`.hibp_api_key
+		order by "name", "created_at"`)
+	return keys, err
+}
+
+// SetEnabled enables or disables the key name in schema.
+// Revoked keys can't be enabled again.
+func SetEnabled(ctx context.Context, db *sqlx.DB, schema, name string, enabled bool) error {
+	return updateKey(ctx, db, name, `update `+schema+`.hibp_api_key set "enabled" = $2 where "name" = $1 and "revoked_at" is null`, enabled)
+}
+
+// Revoke permanently revokes the key name in schema. The key is kept for its usage statistics.
+func Revoke(ctx context.Context, db *sqlx.DB, schema, name string) error {
+	return updateKey(ctx, db, name, `update `+schema+`.hibp_api_key set "enabled" = false, "revoked_at" = now() where "name" = $1 and "revoked_at" is null`)
+}
+
+func updateKey(ctx context.Context, db *sqlx.DB, name, query string, args ...interface{}) error {
+	res, err := db.ExecContext(ctx, query, append([]interface{}{name}, args...)...)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w named %q", ErrNotFound, name)
+	}
+	return nil
+}
+
+type cachedKey struct {
+	key       *model.APIKey
+	principal *model.Principal
+	expires   time.Time
+}
+
+// Authenticator verifies keys against the database. Verified keys are cached
+// for a short time, so disabling or revoking a key takes effect within the TTL.
+// Requests are counted per key and written to the database periodically.
+type Authenticator struct {
+	db     *sqlx.DB
+	schema string
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedKey
+	// invalid holds the expiry of the hashes of rejected keys
+	invalid map[string]time.Time
+	usage   map[int]int64
+}
+
+// NewAuthenticator creates an authenticator of the keys in schema caching them for ttl.
+func NewAuthenticator(db *sqlx.DB, schema string, ttl time.Duration) *Authenticator {
+	return &Authenticator{
+		db:      db,
+		schema:  schema,
+		ttl:     ttl,
+		cache:   make(map[string]cachedKey),
+		invalid: make(map[string]time.Time),
+		usage:   make(map[int]int64),
+	}
+}
+
+// Authenticate returns the principal of key, or ErrInvalidKey, and counts the request.
+func (a *Authenticator) Authenticate(ctx context.Context, key string) (*model.Principal, error) {
+	cached, err := a.lookup(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.usage[cached.key.KeyID]++
+	a.mu.Unlock()
+
+	return cached.principal, nil
+}
+
+// Key returns the stored key, or ErrInvalidKey, without counting a request.
+func (a *Authenticator) Key(ctx context.Context, key string) (*model.APIKey, error) {
+	cached, err := a.lookup(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return cached.key, nil
+}
+
+// lookup returns the cached key, loading it from the database when it expired.
+func (a *Authenticator) lookup(ctx context.Context, key string) (cachedKey, error) {
+	hash := Hash(key)
+
+	a.mu.Lock()
+	cached, ok := a.cache[hash]
+	invalidUntil, invalid := a.invalid[hash]
+	a.mu.Unlock()
+
+	now := time.Now()
+	if ok && now.Before(cached.expires) {
+		return cached, nil
+	}
+	if invalid && now.Before(invalidUntil) {
+		return cachedKey{}, ErrInvalidKey
+	}
+
+	var row model.APIKey
+	err := a.db.GetContext(ctx, &row, `
+		select "key_id", "name", "key_hash", "enabled", "rate_limit", "rate_burst", "daily_quota",
+			"request_count", "last_used_at", "revoked_at", "created_at"
+		from `+a.schema+`.hibp_api_key
+		where "key_hash" = $1 and "enabled" and "revoked_at" is null`, hash)
+	if err == sql.ErrNoRows {
+		a.mu.Lock()
+		delete(a.cache, hash)
+		a.rejectLocked(hash, now)
+		a.mu.Unlock()
+		return cachedKey{}, ErrInvalidKey
+	}
+	if err != nil {
+		return cachedKey{}, err
+	}
+
+	cached = cachedKey{
+		key:       &row,
+		principal: &model.Principal{Name: row.Name, Method: MethodAPIKey, KeyID: row.KeyID},
+		expires:   time.Now().Add(a.ttl),
+	}
+	a.mu.Lock()
+	a.cache[hash] = cached
+	delete(a.invalid, hash)
+	a.mu.Unlock()
+
+	return cached, nil
+}
+
+// rejectLocked caches hash as invalid. Expired entries are dropped when
+// the cache is full, and all of them when that is not enough.
+func (a *Authenticator) rejectLocked(hash string, now time.Time) {
+	if len(a.invalid) >= maxInvalidKeys {
+		for h, expires := range a.invalid {
+			if !now.Before(expires) {
+				delete(a.invalid, h)
+			}
+		}
+		if len(a.invalid) >= maxInvalidKeys {
+			a.invalid = make(map[string]time.Time)
+		}
+	}
+	a.invalid[hash] = now.Add(invalidKeyTTL)
+}
+
+// Cached returns the number of cached keys, including expired and invalid ones.
+func (a *Authenticator) Cached() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.cache) + len(a.invalid)
+}
+
+// Purge drops all cached keys, so the next request of every key is
+// verified against the database again.
+func (a *Authenticator) Purge() {
+	a.mu.Lock()
+	a.cache = make(map[string]cachedKey)
+	a.invalid = make(map[string]time.Time)
+	a.mu.Unlock()
+}
+
+// Flush writes the request counts collected since the last flush. The counts
+// are written in one statement, they are kept for the next flush when it fails.
+func (a *Authenticator) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	usage := a.usage
+	a.usage = make(map[int]int64)
+	a.mu.Unlock()
+
+	if len(usage) == 0 {
+		return nil
+	}
+	keyIDs := make([]int64, 0, len(usage))
+	requests := make([]int64, 0, len(usage))
+	for keyID, n := range usage {
+		keyIDs = append(keyIDs, int64(keyID))
+		requests = append(requests, n)
+	}
+
+	_, err := a.db.ExecContext(ctx, `
+		update `+a.schema+`.hibp_api_key as k
+		set "request_count" = k."request_count" + u."requests", "last_used_at" = now()
+		from unnest($1::integer[], $2::bigint[]) as u("key_id", "requests")
+		where k."key_id" = u."key_id"`, pq.Array(keyIDs), pq.Array(requests))
+	if err != nil {
+		a.mu.Lock()
+		for keyID, n := range usage {
+			a.usage[keyID] += n
+		}
+		a.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Run flushes the request counts every interval until ctx is done.
+func (a *Authenticator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.Flush(ctx); err != nil {
+				fmt.Fprintln(os.Stderr, "error recording API key usage", err)
+			}
+		}
+	}
+}
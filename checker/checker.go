@@ -0,0 +1,132 @@
+// Package checker looks up pwned passwords in-process, without the HTTP API.
+//
+//	c := checker.New(store.New(db, store.Options{}), checker.Options{})
+//	count, err := c.Check(ctx, pwhash.SHA1(password))
+//
+// It is what the serve command answers range requests with, and can be
+// embedded into other services as a library or as HTTP middleware.
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/leesalminen/hibp/model"
+	"github.com/leesalminen/hibp/pwhash"
+	"github.com/leesalminen/hibp/store"
+)
+
+// Fallback fills a hashType prefix that has no rows in the store.
+type Fallback func(hashType, prefix string) ([]model.Row, error)
+
+// Options configure a Checker.
+type Options struct {
+	// Fallback is called for prefixes without rows. Nil disables it.
+	Fallback Fallback
+}
+
+// LookupError is returned when a step of a range lookup fails.
+type LookupError struct {
+	// Op describes the failed step without details of the underlying error.
+	Op  string
+	Err error
+}
+
+func (e *LookupError) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *LookupError) Unwrap() error {
+	return e.Err
+}
+
+// Checker answers range lookups from a store, merged with the custom lists
+// and filtered by the exclusion list. It is safe for concurrent use.
+type Checker struct {
+	store    *store.Store
+	fallback Fallback
+}
+
+// New creates a checker on s.
+func New(s *store.Store, options Options) *Checker {
+	return &Checker{
+		store:    s,
+		fallback: options.Fallback,
+	}
+}
+
+// Store returns the store the checker reads from.
+func (c *Checker) Store() *store.Store {
+	return c.store
+}
+
+// Check returns how often the hex encoded SHA-1 hash was seen, zero if it is not pwned.
+func (c *Checker) Check(ctx context.Context, sha1Hex string) (int, error) {
+	return c.CheckHash(ctx, pwhash.TypeSHA1, sha1Hex)
+}
+
+// CheckPassword returns how often password was seen, zero if it is not pwned.
+func (c *Checker) CheckPassword(ctx context.Context, password string) (int, error) {
+	return c.Check(ctx, pwhash.SHA1(password))
+}
+
+// CheckHash returns how often the hex encoded hash of hashType was seen.
+func (c *Checker) CheckHash(ctx context.Context, hashType, hash string) (int, error) {
+	if !pwhash.Valid(hashType, hash) {
+		return 0, fmt.Errorf("%q is not a valid %s hash", hash, hashType)
+	}
+
+	// a single row lookup is enough unless missing prefixes are filled:
+	if c.fallback == nil {
+		return c.store.Count(ctx, hashType, hash)
+	}
+
+	prefix, suffix := pwhash.Split(hash)
+	rows, err := c.Range(ctx, hashType, prefix)
+	if err != nil {
+		return 0, err
+	}
+	for _, row := range rows {
+		if row.Hash == suffix {
+			return row.Count, nil
+		}
+	}
+	return 0, nil
+}
+
+// Range returns the rows of the hashType prefix sorted by hash, the way the
+// range endpoint reports them. An empty result means the prefix has no rows.
+func (c *Checker) Range(ctx context.Context, hashType, prefix string) ([]model.Row, error) {
+	prefix = strings.ToUpper(prefix)
+
+	found, err := c.store.Range(ctx, hashType, prefix)
+	if err != nil {
+		return nil, &LookupError{Op: "error while executing SQL query", Err: err}
+	}
+
+	// fill missing prefixes from the upstream API if configured:
+	if len(found) == 0 && c.fallback != nil {
+		found, err = c.fallback(hashType, prefix)
+		if err != nil {
+			return nil, &LookupError{Op: "error while fetching range from upstream", Err: err}
+		}
+		// the store filters its rows by count when querying them
+		found = c.store.FilterMinCount(found)
+	}
+
+	// merge the organization specific banned lists:
+	found, err = c.store.MergeCustom(ctx, hashType, prefix, found)
+	if err != nil {
+		return nil, &LookupError{Op: "error while merging custom lists", Err: err}
+	}
+
+	// drop hashes that must never be reported:
+	found, err = c.store.FilterExcluded(ctx, hashType, prefix, found)
+	if err != nil {
+		return nil, &LookupError{Op: "error while filtering exclusions", Err: err}
+	}
+
+	return found, nil
+}
@@ -0,0 +1,89 @@
+package checker
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// defaultMaxMemory is the memory used to parse multipart forms, like net/http.
+const defaultMaxMemory = 32 << 20
+
+// MiddlewareOptions configure Middleware.
+type MiddlewareOptions struct {
+	// Fields are the form fields holding passwords. Defaults to "password".
+	Fields []string
+	// MinCount is how often a password must have been seen to be rejected. Defaults to 1.
+	MinCount int
+	// FailOpen passes requests on when the lookup fails instead of answering 503.
+	FailOpen bool
+	// Rejected answers requests with a pwned password.
+	// Defaults to a 422 plain text response naming the field.
+	Rejected func(w http.ResponseWriter, r *http.Request, field string, count int)
+}
+
+// Middleware returns http.Handler middleware that rejects requests whose
+// form fields contain a pwned password. Requests without any of the
+// fields are passed on unchanged.
+func (c *Checker) Middleware(options MiddlewareOptions) func(http.Handler) http.Handler {
+	fields := options.Fields
+	if len(fields) == 0 {
+		fields = []string{"password"}
+	}
+	minCount := options.MinCount
+	if minCount < 1 {
+		minCount = 1
+	}
+	rejected := options.Rejected
+	if rejected == nil {
+		rejected = rejectPwned
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := parseForm(r); err != nil {
+				http.Error(w, "invalid form", http.StatusBadRequest)
+				return
+			}
+
+			for _, field := range fields {
+				for _, password := range r.Form[field] {
+					if password == "" {
+						continue
+					}
+
+					count, err := c.CheckPassword(r.Context(), password)
+					if err != nil {
+						fmt.Fprintln(os.Stderr, "error checking form field", field, err)
+						if options.FailOpen {
+							continue
+						}
+						http.Error(w, "password check unavailable", http.StatusServiceUnavailable)
+						return
+					}
+
+					if count >= minCount {
+						rejected(w, r, field, count)
+						return
+					}
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// parseForm parses url encoded and multipart request bodies into r.Form,
+// where the next handler can still read them.
+func parseForm(r *http.Request) error {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return r.ParseMultipartForm(defaultMaxMemory)
+	}
+	return r.ParseForm()
+}
+
+func rejectPwned(w http.ResponseWriter, _ *http.Request, field string, count int) {
+	http.Error(w, fmt.Sprintf("the %s has appeared in a data breach %d times, choose a different one", field, count), http.StatusUnprocessableEntity)
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache stores fetched ranges by mode and prefix.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (map[string]int, bool)
+	Set(key string, suffixes map[string]int)
+}
+
+type memoryEntry struct {
+	suffixes map[string]int
+	expires  time.Time
+}
+
+// MemoryCache is an in-memory Cache with a TTL and a maximum number of ranges.
+type MemoryCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// NewMemoryCache creates a cache that keeps ranges for ttl.
+// When it holds maxEntries ranges, expired and then arbitrary ranges are evicted.
+// A maxEntries of zero means no limit.
+func NewMemoryCache(ttl time.Duration, maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]memoryEntry),
+	}
+}
+
+// Get returns the cached range of key, if it has not expired.
+func (m *MemoryCache) Get(key string) (map[string]int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry.suffixes, true
+}
+
+// Set caches the range of key.
+func (m *MemoryCache) Set(key string, suffixes map[string]int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.maxEntries > 0 && len(m.entries) >= m.maxEntries {
+		m.evict()
+	}
+	m.entries[key] = memoryEntry{suffixes: suffixes, expires: time.Now().Add(m.ttl)}
+}
+
+// evict removes expired entries, or an arbitrary one if none expired.
+func (m *MemoryCache) evict() {
+	now := time.Now()
+	for key, entry := range m.entries {
+		if now.After(entry.expires) {
+			delete(m.entries, key)
+		}
+	}
+	if len(m.entries) < m.maxEntries {
+		return
+	}
+	for key := range m.entries {
+		delete(m.entries, key)
+		return
+	}
+}
@@ -0,0 +1,265 @@
+// Package client checks passwords against a k-anonymity range API,
+// either a hibp server or the public pwned passwords API.
+//
+//	c := client.New(client.Options{BaseURL: "https://hibp.example.com"})
+//	count, err := c.IsPwned(ctx, password)
+//
+// Only the first five characters of the password hash are sent to the server.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leesalminen/hibp/pwhash"
+	"github.com/leesalminen/hibp/upstream"
+)
+
+// Modes select the hash type that is looked up.
+const (
+	ModeSHA1 = pwhash.TypeSHA1
+	ModeNTLM = pwhash.TypeNTLM
+)
+
+const (
+	defaultRetries = 3
+	initialBackoff = 250 * time.Millisecond
+	defaultTimeout = 10 * time.Second
+)
+
+// Options configures a Client. The zero value queries the public API in SHA-1 mode.
+type Options struct {
+	// BaseURL of the API, without the /range path. Defaults to the public API.
+	BaseURL string
+	// HTTPClient used for requests. Defaults to a client with a 10 second timeout.
+	HTTPClient *http.Client
+	// Mode is ModeSHA1 or ModeNTLM. Defaults to ModeSHA1. NTLM ranges are
+	// only served by the public API.
+	Mode string
+	// Padding asks the server to pad responses with zero count suffixes
+	// so the response size does not reveal the range.
+	Padding bool
+	// Retries of network, rate limit and server errors. Defaults to 3, negative disables retries.
+	Retries int
+	// Cache of fetched ranges. Nil disables caching.
+	Cache Cache
+	// UserAgent sent with requests.
+	UserAgent string
+	// APIKey sent in the hibp-api-key header, if set.
+	APIKey string
+}
+
+// Client looks up password hashes by prefix. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	mode       string
+	padding    bool
+	retries    int
+	cache      Cache
+	userAgent  string
+	apiKey     string
+}
+
+// StatusError is returned when the server answers with an unexpected status.
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("range request failed with status: %d", e.StatusCode)
+}
+
+// New creates a client with opts.
+func New(opts Options) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(opts.BaseURL, "/"),
+		httpClient: opts.HTTPClient,
+		mode:       opts.Mode,
+		padding:    opts.Padding,
+		retries:    opts.Retries,
+		cache:      opts.Cache,
+		userAgent:  opts.UserAgent,
+		apiKey:     opts.APIKey,
+	}
+	if c.baseURL == "" {
+		c.baseURL = upstream.DefaultBaseURL
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	if c.mode == "" {
+		c.mode = ModeSHA1
+	}
+	if c.retries == 0 {
+		c.retries = defaultRetries
+	} else if c.retries < 0 {
+		c.retries = 0
+	}
+	return c
+}
+
+// IsPwned returns how often password was seen, zero if it was not.
+func (c *Client) IsPwned(ctx context.Context, password string) (int, error) {
+	return c.Count(ctx, pwhash.Hash(c.mode, password))
+}
+
+// Count returns how often the hex encoded hash of the client's mode was seen.
+func (c *Client) Count(ctx context.Context, hash string) (int, error) {
+	if !pwhash.Valid(c.mode, hash) {
+		return 0, fmt.Errorf("%q is not a valid %s hash", hash, c.mode)
+	}
+
+	prefix, suffix := pwhash.Split(hash)
+	suffixes, err := c.Range(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	return suffixes[suffix], nil
+}
+
+// Range returns the upper case suffixes of prefix with their counts.
+// Padding lines are left out.
+func (c *Client) Range(ctx context.Context, prefix string) (map[string]int, error) {
+	prefix = strings.ToUpper(prefix)
+	key := c.mode + ":" + prefix
+	if c.cache != nil {
+		if suffixes, ok := c.cache.Get(key); ok {
+			return suffixes, nil
+		}
+	}
+
+	suffixes, err := c.fetchWithRetry(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cache != nil {
+		c.cache.Set(key, suffixes)
+	}
+	return suffixes, nil
+}
+
+// fetchWithRetry calls fetch, retrying network, rate limit
+// and server errors with exponential backoff.
+func (c *Client) fetchWithRetry(ctx context.Context, prefix string) (map[string]int, error) {
+	var lastErr error
+	backoff := initialBackoff
+
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			wait := backoff
+			var statusErr *StatusError
+			if errors.As(lastErr, &statusErr) && statusErr.RetryAfter > wait {
+				wait = statusErr.RetryAfter
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			backoff *= 2
+		}
+
+		suffixes, err := c.fetch(ctx, prefix)
+		if err == nil {
+			return suffixes, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		lastErr = err
+		if !retryable(err) {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("after %d attempts, last error: %w", c.retries+1, lastErr)
+}
+
+// retryable reports whether a failed request should be retried.
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		// Network errors should be retried
+		return true
+	}
+	return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+}
+
+// fetch requests the range of prefix once. A 404 response is an empty range.
+func (c *Client) fetch(ctx context.Context, prefix string) (map[string]int, error) {
+	url := fmt.Sprintf("%s/range/%s", c.baseURL, prefix)
+	if c.mode == ModeNTLM {
+		url += "?mode=ntlm"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.padding {
+		req.Header.Set("Add-Padding", "true")
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("hibp-api-key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// a hibp server with the default --not-found-behavior answers
+		// ranges without rows with 404, the public API never does
+		io.Copy(io.Discard, resp.Body)
+		return map[string]int{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, &StatusError{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp)}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	suffixes := make(map[string]int)
+	for _, line := range strings.Split(string(body), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		suffix, count, err := upstream.ParseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			// Padding
+			continue
+		}
+		suffixes[strings.ToUpper(suffix)] += count
+	}
+	return suffixes, nil
+}
+
+// retryAfter parses the Retry-After header given in seconds.
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// password is pwned 3 times on the test servers, password NTLM 7 times.
+const (
+	password       = "password"
+	pwnedSHA1      = "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8"
+	pwnedNTLM      = "8846F7EAEE8FB117AD06BDD830B7586C"
+	unknownSuffix  = "00000000000000000000000000000000000"
+	notFoundPrefix = "00000"
+)
+
+// rangeServer is a stand-in range API. Prefixes without rows are answered
+// with 404 like a hibp server with the default --not-found-behavior.
+type rangeServer struct {
+	*httptest.Server
+	requests int32
+	// failures is the number of requests answered with status 503 first
+	failures int32
+	lastReq  *http.Request
+}
+
+func newRangeServer(t *testing.T, failures int32) *rangeServer {
+	s := &rangeServer{failures: failures}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&s.requests, 1) <= s.failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		s.lastReq = r
+
+		hash, lines := pwnedSHA1, []string{unknownSuffix + ":0", pwnedSHA1[5:] + ":3"}
+		if r.URL.Query().Get("mode") == "ntlm" {
+			hash, lines = pwnedNTLM, []string{pwnedNTLM[5:] + ":7"}
+		}
+		switch prefix := strings.TrimPrefix(r.URL.Path, "/range/"); prefix {
+		case hash[:5]:
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprint(w, strings.Join(lines, "\r\n"))
+		case notFoundPrefix:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "The hash prefix was not in a valid format")
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestIsPwned(t *testing.T) {
+	s := newRangeServer(t, 0)
+
+	tests := []struct {
+		mode string
+		want int
+	}{
+		{ModeSHA1, 3},
+		{ModeNTLM, 7},
+	}
+	for _, test := range tests {
+		c := New(Options{BaseURL: s.URL, Mode: test.mode, Padding: true})
+		count, err := c.IsPwned(context.Background(), password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != test.want {
+			t.Errorf("%s count %d, want %d", test.mode, count, test.want)
+		}
+		if s.lastReq.Header.Get("Add-Padding") != "true" {
+			t.Errorf("%s request without Add-Padding", test.mode)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	s := newRangeServer(t, 0)
+	c := New(Options{BaseURL: s.URL})
+
+	tests := []struct {
+		name string
+		hash string
+		want int
+	}{
+		{"pwned", pwnedSHA1, 3},
+		{"lower case", strings.ToLower(pwnedSHA1), 3},
+		{"padding line", pwnedSHA1[:5] + unknownSuffix, 0},
+		{"not in range", pwnedSHA1[:5] + strings.Repeat("F", 35), 0},
+		{"404 range", notFoundPrefix + strings.Repeat("A", 35), 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			count, err := c.Count(context.Background(), test.hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != test.want {
+				t.Errorf("count %d, want %d", count, test.want)
+			}
+		})
+	}
+
+	if _, err := c.Count(context.Background(), "ZZZZZ"); err == nil {
+		t.Error("Count() of an invalid hash succeeded")
+	}
+}
+
+func TestRetries(t *testing.T) {
+	s := newRangeServer(t, 2)
+	c := New(Options{BaseURL: s.URL})
+
+	count, err := c.Count(context.Background(), pwnedSHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || atomic.LoadInt32(&s.requests) != 3 {
+		t.Errorf("count %d after %d requests, want 3 after 3", count, s.requests)
+	}
+
+	s = newRangeServer(t, 10)
+	c = New(Options{BaseURL: s.URL, Retries: -1})
+	_, err = c.Count(context.Background(), pwnedSHA1)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Count() = %v without retries, want status 503", err)
+	}
+}
+
+func TestNotRetried(t *testing.T) {
+	s := newRangeServer(t, 0)
+	c := New(Options{BaseURL: s.URL})
+
+	// the stand-in rejects prefixes it does not know with 400
+	_, err := c.Range(context.Background(), "FFFFF")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Range() = %v, want status 400", err)
+	}
+	if n := atomic.LoadInt32(&s.requests); n != 1 {
+		t.Errorf("%d requests, want 1", n)
+	}
+}
+
+func TestCache(t *testing.T) {
+	s := newRangeServer(t, 0)
+	c := New(Options{BaseURL: s.URL, Cache: NewMemoryCache(time.Minute, 10)})
+
+	for _, hash := range []string{pwnedSHA1, pwnedSHA1, notFoundPrefix + strings.Repeat("A", 35), notFoundPrefix + strings.Repeat("B", 35)} {
+		if _, err := c.Count(context.Background(), hash); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&s.requests); n != 2 {
+		t.Errorf("%d requests, want one per prefix", n)
+	}
+}
@@ -0,0 +1,208 @@
+package apikey
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/apikey"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/spf13/cobra"
+
+	// import postgres
+	_ "github.com/lib/pq"
+)
+
+// Command is the cobra command.
+var Command = &cobra.Command{
+	Use:   "apikey",
+	Short: "Manage the API keys of the range endpoint",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+		os.Exit(1)
+	},
+}
+
+var createCommand = &cobra.Command{
+	Use:   "create name",
+	Short: "Create a key and print it once",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runCreate,
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List keys and their usage",
+	RunE:  runList,
+}
+
+var limitCommand = &cobra.Command{
+	Use:   "limit name",
+	Short: "Replace the rate limit and daily quota of a key",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runLimit,
+}
+
+var revokeCommand = &cobra.Command{
+	Use:   "revoke name...",
+	Short: "Permanently revoke keys",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runRevoke,
+}
+
+var enableCommand = &cobra.Command{
+	Use:   "enable name...",
+	Short: "Enable disabled keys",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSetEnabled(cmd, args, true)
+	},
+}
+
+var disableCommand = &cobra.Command{
+	Use:   "disable name...",
+	Short: "Disable keys until they are enabled again",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSetEnabled(cmd, args, false)
+	},
+}
+
+type commandConfig struct {
+	dsn    string
+	schema string
+	limits apikey.Limits
+}
+
+var config = new(commandConfig)
+
+func initFlags() {
+	Command.PersistentFlags().StringVar(&config.dsn, "dsn", "", "Database connection string")
+	Command.PersistentFlags().StringVar(&config.schema, "schema", dataset.DefaultSchema, "Schema of the instance, as passed to migrate")
+
+	for _, cmd := range []*cobra.Command{createCommand, limitCommand} {
+		cmd.Flags().Float64Var(&config.limits.Rate, "rate", 0, "Requests per second, 0 uses the --rate-limit of serve")
+		cmd.Flags().IntVar(&config.limits.Burst, "burst", 0, "Requests allowed at once, 0 uses the --rate-burst of serve")
+		cmd.Flags().Int64Var(&config.limits.DailyQuota, "daily-quota", 0, "Requests per UTC day, 0 means no quota")
+	}
+}
+
+func init() {
+	initFlags()
+	Command.AddCommand(createCommand)
+	Command.AddCommand(listCommand)
+	Command.AddCommand(limitCommand)
+	Command.AddCommand(revokeCommand)
+	Command.AddCommand(enableCommand)
+	Command.AddCommand(disableCommand)
+}
+
+func connect() *sqlx.DB {
+	db, err := sqlx.Connect("postgres", config.dsn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error establishing database connection", err)
+		os.Exit(1)
+	}
+	return db
+}
+
+func runCreate(cmd *cobra.Command, args []string) error {
+	db := connect()
+	defer db.Close()
+
+	key, err := apikey.Create(cmd.Context(), db, config.schema, args[0], config.limits)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error creating API key", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintln(os.Stderr, "Store the key now, it can't be shown again:")
+	fmt.Println(key)
+	return nil
+}
+
+func runList(cmd *cobra.Command, _ []string) error {
+	db := connect()
+	defer db.Close()
+
+	keys, err := apikey.List(cmd.Context(), db, config.schema)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error listing API keys", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tRATE\tBURST\tDAILY QUOTA\tREQUESTS\tLAST USED\tCREATED")
+	for _, k := range keys {
+		status := "enabled"
+		if k.RevokedAt != nil {
+			status = "revoked"
+		} else if !k.Enabled {
+			status = "disabled"
+		}
+		lastUsed := "never"
+		if k.LastUsedAt != nil {
+			lastUsed = k.LastUsedAt.Format("2006-01-02 15:04:05")
+		}
+		rate, burst, quota := "default", "default", "none"
+		if k.RateLimit != nil {
+			rate = fmt.Sprintf("%g/s", *k.RateLimit)
+		}
+		if k.RateBurst != nil {
+			burst = fmt.Sprint(*k.RateBurst)
+		}
+		if k.DailyQuota != nil {
+			quota = fmt.Sprint(*k.DailyQuota)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", k.Name, status, rate, burst, quota, k.RequestCount, lastUsed, k.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	return w.Flush()
+}
+
+func runLimit(cmd *cobra.Command, args []string) error {
+	db := connect()
+	defer db.Close()
+
+	if err := apikey.SetLimits(cmd.Context(), db, config.schema, args[0], config.limits); err != nil {
+		fmt.Fprintln(os.Stderr, "error updating API key", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Updated the limits of %s\n", args[0])
+	return nil
+}
+
+func runRevoke(cmd *cobra.Command, args []string) error {
+	db := connect()
+	defer db.Close()
+
+	for _, name := range args {
+		if err := apikey.Revoke(cmd.Context(), db, config.schema, name); err != nil {
+			fmt.Fprintln(os.Stderr, "error revoking API key", err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("Revoked %d keys\n", len(args))
+	return nil
+}
+
+func runSetEnabled(cmd *cobra.Command, args []string, enabled bool) error {
+	db := connect()
+	defer db.Close()
+
+	for _, name := range args {
+		if err := apikey.SetEnabled(cmd.Context(), db, config.schema, name, enabled); err != nil {
+			fmt.Fprintln(os.Stderr, "error updating API key", err)
+			os.Exit(1)
+		}
+	}
+
+	action := "Disabled"
+	if enabled {
+		action = "Enabled"
+	}
+	fmt.Printf("%s %d keys\n", action, len(args))
+	return nil
+}
@@ -0,0 +1,375 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/pwhash"
+	"github.com/leesalminen/hibp/store"
+	"github.com/spf13/cobra"
+
+	// import postgres
+	_ "github.com/lib/pq"
+)
+
+// Command is the cobra command.
+var Command = &cobra.Command{
+	Use:   "audit",
+	Short: "Report compromised accounts in a dump of password hashes",
+	Long: `Report compromised accounts in a dump of password hashes.
+
+Input formats:
+  ntds   secretsdump output, user:rid:lmhash:nthash:::, or user:nthash
+  plain  one hash per line, the line number is used as the account
+  csv    account,hash
+
+The hashes are looked up directly in the database.`,
+	RunE: run,
+}
+
+// Supported values of the --format flag.
+const (
+	formatNTDS  = "ntds"
+	formatPlain = "plain"
+	formatCSV   = "csv"
+)
+
+// Supported values of the --report-format flag.
+const (
+	reportCSV  = "csv"
+	reportJSON = "json"
+)
+
+// typeAuto detects the hash type by the length of the hash.
+const typeAuto = "auto"
+
+// defaultWorkers matches the worker pool of data-import.
+const defaultWorkers = 32
+
+type commandConfig struct {
+	dsn          string
+	schema       string
+	input        string
+	format       string
+	hashType     string
+	output       string
+	reportFormat string
+	workers      int
+	minCount     int
+}
+
+var config = new(commandConfig)
+
+func initFlags() {
+	Command.Flags().StringVar(&config.dsn, "dsn", "", "Database connection string")
+	Command.Flags().StringVar(&config.schema, "schema", dataset.DefaultSchema, "Schema of the instance, as passed to migrate")
+	Command.Flags().StringVar(&config.input, "input", "-", "Hash dump to audit, - for stdin")
+	Command.Flags().StringVar(&config.format, "format", formatNTDS, "Input format: ntds, plain or csv")
+	Command.Flags().StringVar(&config.hashType, "type", typeAuto, "Hash type of plain and csv input: auto, sha1 or ntlm")
+	Command.Flags().StringVar(&config.output, "output", "-", "Report file, - for stdout")
+	Command.Flags().StringVar(&config.reportFormat, "report-format", reportCSV, "Report format: csv or json")
+	Command.Flags().IntVar(&config.workers, "workers", defaultWorkers, "Number of concurrent lookups")
+	Command.Flags().IntVar(&config.minCount, "min-count", 0, "Only report hashes seen at least this many times")
+}
+
+func init() {
+	initFlags()
+}
+
+// account is a single account of the dump.
+type account struct {
+	Name     string `json:"account"`
+	HashType string `json:"hash_type"`
+	hash     string
+	Count    int `json:"count"`
+}
+
+// Summary holds the statistics of an audit.
+type Summary struct {
+	Accounts       int     `json:"accounts"`
+	Invalid        int     `json:"invalid"`
+	Compromised    int     `json:"compromised"`
+	CompromisedPct float64 `json:"compromised_pct"`
+	UniqueHashes   int     `json:"unique_hashes"`
+	SharedHashes   int     `json:"shared_hashes"`
+	MaxCount       int     `json:"max_count"`
+}
+
+type report struct {
+	Summary     Summary   `json:"summary"`
+	Compromised []account `json:"compromised"`
+}
+
+func run(cmd *cobra.Command, _ []string) error {
+	switch config.format {
+	case formatNTDS, formatPlain, formatCSV:
+	default:
+		fmt.Fprintln(os.Stderr, "invalid --format", config.format, "expected one of ntds, plain, csv")
+		os.Exit(1)
+	}
+	if config.reportFormat != reportCSV && config.reportFormat != reportJSON {
+		fmt.Fprintln(os.Stderr, "invalid --report-format", config.reportFormat, "expected csv or json")
+		os.Exit(1)
+	}
+	if config.workers < 1 {
+		fmt.Fprintln(os.Stderr, "--workers must be at least 1")
+		os.Exit(1)
+	}
+
+	in := os.Stdin
+	if config.input != "-" {
+		f, err := os.Open(config.input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error opening input", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	accounts, invalid, err := readAccounts(in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error reading input", err)
+		os.Exit(1)
+	}
+
+	db, err := sqlx.Connect("postgres", config.dsn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error establishing database connection", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	layout, err := dataset.LoadLayout(cmd.Context(), db, config.schema)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error loading the table layout", err)
+		os.Exit(1)
+	}
+
+	counts, err := lookup(cmd.Context(), store.New(db, store.Options{MinCount: config.minCount, Layout: layout}), accounts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error looking up hashes", err)
+		os.Exit(1)
+	}
+
+	r := report{
+		Summary: Summary{
+			Accounts:     len(accounts),
+			Invalid:      invalid,
+			UniqueHashes: len(counts),
+		},
+		Compromised: []account{},
+	}
+
+	seen := make(map[string]int, len(counts))
+	for _, a := range accounts {
+		seen[a.HashType+a.hash]++
+	}
+	for _, n := range seen {
+		if n > 1 {
+			r.Summary.SharedHashes++
+		}
+	}
+
+	for _, a := range accounts {
+		a.Count = counts[a.HashType+a.hash]
+		if a.Count == 0 {
+			continue
+		}
+		r.Compromised = append(r.Compromised, a)
+		if a.Count > r.Summary.MaxCount {
+			r.Summary.MaxCount = a.Count
+		}
+	}
+	sort.SliceStable(r.Compromised, func(i, j int) bool { return r.Compromised[i].Count > r.Compromised[j].Count })
+
+	r.Summary.Compromised = len(r.Compromised)
+	if len(accounts) > 0 {
+		r.Summary.CompromisedPct = float64(len(r.Compromised)) * 100 / float64(len(accounts))
+	}
+
+	out := os.Stdout
+	if config.output != "-" {
+		f, err := os.Create(config.output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error creating report", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := writeReport(out, r); err != nil {
+		fmt.Fprintln(os.Stderr, "error writing report", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "Audited %d accounts, %d compromised (%.1f%%), %d invalid lines\n",
+		r.Summary.Accounts, r.Summary.Compromised, r.Summary.CompromisedPct, r.Summary.Invalid)
+	return nil
+}
+
+// readAccounts parses the input according to --format and returns the
+// accounts with a valid hash and the number of skipped lines.
+func readAccounts(in io.Reader) ([]account, int, error) {
+	var accounts []account
+	invalid := 0
+
+	add := func(name, hash, hashType string) {
+		hash = strings.ToUpper(strings.TrimSpace(hash))
+		if hashType == typeAuto {
+			switch len(hash) {
+			case pwhash.Length(pwhash.TypeSHA1):
+				hashType = pwhash.TypeSHA1
+			case pwhash.Length(pwhash.TypeNTLM):
+				hashType = pwhash.TypeNTLM
+			}
+		}
+		if !pwhash.Valid(hashType, hash) {
+			invalid++
+			return
+		}
+		accounts = append(accounts, account{Name: name, HashType: hashType, hash: hash})
+	}
+
+	if config.format == formatCSV {
+		r := csv.NewReader(in)
+		r.FieldsPerRecord = -1
+		for {
+			record, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+			if len(record) != 2 {
+				invalid++
+				continue
+			}
+			add(record[0], record[1], config.hashType)
+		}
+		return accounts, invalid, nil
+	}
+
+	scanner := bufio.NewScanner(in)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if config.format == formatPlain {
+			add(strconv.Itoa(line), text, config.hashType)
+			continue
+		}
+
+		// secretsdump: user:rid:lmhash:nthash:::
+		fields := strings.Split(text, ":")
+		switch {
+		case len(fields) >= 4:
+			add(fields[0], fields[3], pwhash.TypeNTLM)
+		case len(fields) == 2:
+			add(fields[0], fields[1], pwhash.TypeNTLM)
+		default:
+			invalid++
+		}
+	}
+
+	return accounts, invalid, scanner.Err()
+}
+
+// lookup counts every distinct hash of accounts using a pool of workers.
+// The result is keyed by hash type and hash.
+func lookup(ctx context.Context, s *store.Store, accounts []account) (map[string]int, error) {
+	work := make(chan account)
+	counts := make(map[string]int)
+	var mu sync.Mutex
+	var firstErr error
+
+	var wg sync.WaitGroup
+	for i := 0; i < config.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range work {
+				count, err := s.Count(ctx, a.HashType, a.hash)
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				counts[a.HashType+a.hash] = count
+				mu.Unlock()
+			}
+		}()
+	}
+
+	queued := make(map[string]bool, len(accounts))
+	for _, a := range accounts {
+		key := a.HashType + a.hash
+		if queued[key] {
+			continue
+		}
+		queued[key] = true
+		work <- a
+	}
+	close(work)
+	wg.Wait()
+
+	return counts, firstErr
+}
+
+// records returns the statistics as name and value records, named like the JSON fields.
+func (s Summary) records() [][]string {
+	return [][]string{
+		{"accounts", strconv.Itoa(s.Accounts)},
+		{"invalid", strconv.Itoa(s.Invalid)},
+		{"compromised", strconv.Itoa(s.Compromised)},
+		{"compromised_pct", strconv.FormatFloat(s.CompromisedPct, 'f', 1, 64)},
+		{"unique_hashes", strconv.Itoa(s.UniqueHashes)},
+		{"shared_hashes", strconv.Itoa(s.SharedHashes)},
+		{"max_count", strconv.Itoa(s.MaxCount)},
+	}
+}
+
+// writeReport writes r as JSON or as CSV. The CSV report lists the
+// compromised accounts followed by an empty line and the summary section.
+func writeReport(out io.Writer, r report) error {
+	if config.reportFormat == reportJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+
+	w := csv.NewWriter(out)
+	w.Write([]string{"account", "hash_type", "count"})
+	for _, a := range r.Compromised {
+		w.Write([]string{a.Name, a.HashType, strconv.Itoa(a.Count)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	// an empty line separates the sections, CSV readers skip it
+	if _, err := io.WriteString(out, "\n"); err != nil {
+		return err
+	}
+	w.Write([]string{"statistic", "value"})
+	w.WriteAll(r.Summary.records())
+	return w.Error()
+}
@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestWriteCSVReport(t *testing.T) {
+	config.reportFormat = reportCSV
+	defer func() { config.reportFormat = reportCSV }()
+
+	r := report{
+		Summary: Summary{Accounts: 4, Invalid: 1, Compromised: 2, CompromisedPct: 50, UniqueHashes: 3, SharedHashes: 1, MaxCount: 42},
+		Compromised: []account{
+			{Name: "alice", HashType: "ntlm", Count: 42},
+			{Name: "bob", HashType: "ntlm", Count: 3},
+		},
+	}
+
+	var sb strings.Builder
+	if err := writeReport(&sb, r); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "account,hash_type,count\n" +
+		"alice,ntlm,42\n" +
+		"bob,ntlm,3\n" +
+		"\n" +
+		"statistic,value\n" +
+		"accounts,4\n" +
+		"invalid,1\n" +
+		"compromised,2\n" +
+		"compromised_pct,50.0\n" +
+		"unique_hashes,3\n" +
+		"shared_hashes,1\n" +
+		"max_count,42\n"
+	if sb.String() != want {
+		t.Fatalf("report\n%s\nwant\n%s", sb.String(), want)
+	}
+
+	// CSV readers skip the empty line between the sections
+	reader := csv.NewReader(strings.NewReader(sb.String()))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 11 {
+		t.Errorf("%d records, want 11", len(records))
+	}
+}
@@ -0,0 +1,150 @@
+package check
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/client"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/pwhash"
+	"github.com/leesalminen/hibp/store"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	// import postgres
+	_ "github.com/lib/pq"
+)
+
+// Command is the cobra command.
+var Command = &cobra.Command{
+	Use:   "check",
+	Short: "Check whether a password or hash is pwned",
+	Long: `Check whether a password or hash is pwned.
+
+The password is read from a prompt without echo, or from the first line of
+stdin when it is not a terminal. Use --hash to check a hash instead.
+
+Prints how often the password was seen. Exits with status 2 when the password
+is pwned, 0 when it is not and 1 on errors.`,
+	RunE: run,
+}
+
+// exitPwned is the exit status when the password is pwned.
+const exitPwned = 2
+
+type commandConfig struct {
+	dsn      string
+	schema   string
+	server   string
+	hash     string
+	hashType string
+	minCount int
+	timeout  time.Duration
+	apiKey   string
+}
+
+var config = new(commandConfig)
+
+func initFlags() {
+	Command.Flags().StringVar(&config.dsn, "dsn", "", "Database connection string, used when --server is not set")
+	Command.Flags().StringVar(&config.schema, "schema", dataset.DefaultSchema, "Schema of the instance, as passed to migrate")
+	Command.Flags().StringVar(&config.server, "server", "", "Base URL of a range API to query instead of the database")
+	Command.Flags().StringVar(&config.hash, "hash", "", "Hash to check instead of a password")
+	Command.Flags().StringVar(&config.hashType, "type", pwhash.TypeSHA1, "Hash type: sha1 or ntlm, NTLM ranges are only served by the public API when --server is set")
+	Command.Flags().IntVar(&config.minCount, "min-count", 0, "Only report hashes seen at least this many times, when using the database")
+	Command.Flags().DurationVar(&config.timeout, "timeout", 10*time.Second, "Timeout of requests to --server")
+	Command.Flags().StringVar(&config.apiKey, "api-key", "", "API key sent to --server in the hibp-api-key header")
+}
+
+func init() {
+	initFlags()
+}
+
+func run(cmd *cobra.Command, _ []string) error {
+	if config.hashType != pwhash.TypeSHA1 && config.hashType != pwhash.TypeNTLM {
+		fmt.Fprintln(os.Stderr, "invalid --type", config.hashType, "expected sha1 or ntlm")
+		os.Exit(1)
+	}
+
+	hash := strings.ToUpper(strings.TrimSpace(config.hash))
+	if hash == "" {
+		password, err := readPassword()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error reading password", err)
+			os.Exit(1)
+		}
+		hash = pwhash.Hash(config.hashType, password)
+	}
+	if !pwhash.Valid(config.hashType, hash) {
+		fmt.Fprintf(os.Stderr, "%q is not a valid %s hash\n", config.hash, config.hashType)
+		os.Exit(1)
+	}
+
+	var count int
+	var err error
+	if config.server != "" {
+		count, err = checkServer(cmd, hash)
+	} else {
+		count, err = checkStore(cmd, hash)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error checking hash", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(count)
+	if count > 0 {
+		os.Exit(exitPwned)
+	}
+	return nil
+}
+
+// readPassword prompts for the password without echo,
+// or reads the first line of stdin when it is not a terminal.
+func readPassword() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Password: ")
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(password), err
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// checkServer looks the hash up with a k-anonymity range request.
+func checkServer(cmd *cobra.Command, hash string) (int, error) {
+	c := client.New(client.Options{
+		BaseURL:    config.server,
+		HTTPClient: &http.Client{Timeout: config.timeout},
+		Mode:       config.hashType,
+		Padding:    true,
+		APIKey:     config.apiKey,
+	})
+	return c.Count(cmd.Context(), hash)
+}
+
+// checkStore looks the hash up in the database.
+func checkStore(cmd *cobra.Command, hash string) (int, error) {
+	db, err := sqlx.Connect("postgres", config.dsn)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	layout, err := dataset.LoadLayout(cmd.Context(), db, config.schema)
+	if err != nil {
+		return 0, err
+	}
+	return store.New(db, store.Options{MinCount: config.minCount, Layout: layout}).Count(cmd.Context(), config.hashType, hash)
+}
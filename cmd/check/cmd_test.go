@@ -0,0 +1,46 @@
+package check
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestCheckServerNotFound checks a clean hash against a hibp server that
+// answers ranges without rows with 404, its default --not-found-behavior.
+func TestCheckServerNotFound(t *testing.T) {
+	var requested string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer s.Close()
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	// a pwned or failed check exits the test binary instead of returning
+	Command.SetArgs([]string{"--server", s.URL, "--hash", strings.Repeat("0", 40)})
+	err = Command.ExecuteContext(context.Background())
+	w.Close()
+	out, _ := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if requested != "/range/00000" {
+		t.Errorf("requested %s, want /range/00000", requested)
+	}
+	if string(out) != "0\n" {
+		t.Errorf("printed %q, want 0", out)
+	}
+}
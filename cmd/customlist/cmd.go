@@ -0,0 +1,202 @@
+package customlist
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/cmd/entries"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/model"
+	"github.com/spf13/cobra"
+
+	// import postgres
+	_ "github.com/lib/pq"
+)
+
+// Command is the cobra command.
+var Command = &cobra.Command{
+	Use:   "custom-list",
+	Short: "Manage organization specific banned password lists",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+		os.Exit(1)
+	},
+}
+
+var addCommand = &cobra.Command{
+	Use:   "add [entry...]",
+	Short: "Add passwords or hashes to a custom list",
+	RunE:  runAdd,
+}
+
+var removeCommand = &cobra.Command{
+	Use:   "remove [entry...]",
+	Short: "Remove passwords or hashes from a custom list",
+	RunE:  runRemove,
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List custom list entries",
+	RunE:  runList,
+}
+
+type commandConfig struct {
+	dsn       string
+	schema    string
+	source    string
+	entryType string
+	file      string
+	count     int
+	all       bool
+}
+
+var config = new(commandConfig)
+
+func initFlags() {
+	Command.PersistentFlags().StringVar(&config.dsn, "dsn", "", "Database connection string")
+	Command.PersistentFlags().StringVar(&config.schema, "schema", dataset.DefaultSchema, "Schema of the instance, as passed to migrate")
+
+	for _, c := range []*cobra.Command{addCommand, removeCommand} {
+		c.Flags().StringVar(&config.source, "source", "", "Label of the list the entries belong to")
+		c.Flags().StringVar(&config.entryType, "type", entries.TypePassword, "Type of the entries: password, sha1 or ntlm")
+		c.Flags().StringVar(&config.file, "file", "", "Read entries from a file, one per line, - for stdin")
+	}
+	addCommand.Flags().IntVar(&config.count, "count", 1, "Count stored for the entries")
+	removeCommand.Flags().BoolVar(&config.all, "all", false, "Remove all entries of the source")
+	listCommand.Flags().StringVar(&config.source, "source", "", "Only list entries of this source")
+}
+
+func init() {
+	initFlags()
+	Command.AddCommand(addCommand)
+	Command.AddCommand(removeCommand)
+	Command.AddCommand(listCommand)
+}
+
+// connect connects to the database and loads the layout of --schema.
+func connect(ctx context.Context) (*sqlx.DB, dataset.Layout) {
+	db, err := sqlx.Connect("postgres", config.dsn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error establishing database connection", err)
+		os.Exit(1)
+	}
+	layout, err := dataset.LoadLayout(ctx, db, config.schema)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error loading the table layout", err)
+		os.Exit(1)
+	}
+	return db, layout
+}
+
+func runAdd(cmd *cobra.Command, args []string) error {
+	if config.source == "" {
+		fmt.Fprintln(os.Stderr, "the --source flag is required")
+		os.Exit(1)
+	}
+
+	hashes, err := entries.Read(args, config.file, config.entryType)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error reading entries", err)
+		os.Exit(1)
+	}
+
+	db, layout := connect(cmd.Context())
+	defer db.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range hashes {
+		_, err := tx.Exec(`
+			insert into `+layout.Qualified("hibp_custom")+` ("hash_type", "prefix", "hash", "source", "count")
+			values ($1, $2, $3, $4, $5)
+			on conflict ("hash_type", "prefix", "hash", "source") do update set "count" = excluded."count"`,
+			entry.HashType, entry.Prefix, entry.Suffix, config.source, config.count)
+		if err != nil {
+			tx.Rollback()
+			fmt.Fprintln(os.Stderr, "error adding custom list entry", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added %d hashes to %s\n", len(hashes), config.source)
+	return nil
+}
+
+func runRemove(cmd *cobra.Command, args []string) error {
+	if config.source == "" {
+		fmt.Fprintln(os.Stderr, "the --source flag is required")
+		os.Exit(1)
+	}
+
+	db, layout := connect(cmd.Context())
+	defer db.Close()
+
+	if config.all {
+		res, err := db.Exec(`delete from `+layout.Qualified("hibp_custom")+` where "source" = $1`, config.source)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error removing custom list", err)
+			os.Exit(1)
+		}
+		removed, _ := res.RowsAffected()
+		fmt.Printf("Removed %d hashes from %s\n", removed, config.source)
+		return nil
+	}
+
+	hashes, err := entries.Read(args, config.file, config.entryType)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error reading entries", err)
+		os.Exit(1)
+	}
+
+	var removed int64
+	for _, entry := range hashes {
+		res, err := db.Exec(`
+			delete from `+layout.Qualified("hibp_custom")+`
+			where "hash_type" = $1 and "prefix" = $2 and "hash" = $3 and "source" = $4`,
+			entry.HashType, entry.Prefix, entry.Suffix, config.source)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error removing custom list entry", err)
+			os.Exit(1)
+		}
+		n, _ := res.RowsAffected()
+		removed += n
+	}
+
+	fmt.Printf("Removed %d hashes from %s\n", removed, config.source)
+	return nil
+}
+
+func runList(cmd *cobra.Command, _ []string) error {
+	db, layout := connect(cmd.Context())
+	defer db.Close()
+
+	var entries []model.CustomEntry
+	err := db.Select(&entries, `
+		select "hash_type", "prefix", "hash", "source", "count", "created_at"
+		from `+layout.Qualified("hibp_custom")+`
+		where $1 = '' or "source" = $1
+		order by "source", "hash_type", "prefix", "hash"`, config.source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error listing custom list entries", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tTYPE\tHASH\tCOUNT\tCREATED")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s%s\t%d\t%s\n",
+			entry.Source, entry.HashType, entry.Prefix, entry.Hash, entry.Count, entry.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,135 @@
+package dataimport
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/dataset"
+)
+
+// numPrefixes is the number of five character hash prefixes.
+const numPrefixes = 16 * 16 * 16 * 16 * 16
+
+// checkpoint tracks which ranges of an API import are committed. Ranges are
+// committed out of order, the checkpoint is the first prefix whose range is
+// not, so an interrupted import resumes from there. A range that failed to
+// fetch or flush holds the checkpoint back: the import then fails without
+// being marked finished, so --resume imports the range again.
+// It is safe for concurrent use by the writers.
+type checkpoint struct {
+	db       *sqlx.DB
+	layout   dataset.Layout
+	importID int
+
+	mu   sync.Mutex
+	done []bool
+	next int
+}
+
+// newCheckpoint creates the checkpoint of an import resuming from prefix,
+// or starting from the beginning if prefix is empty.
+func newCheckpoint(db *sqlx.DB, layout dataset.Layout, importID int, prefix string) (*checkpoint, error) {
+	c := &checkpoint{db: db, layout: layout, importID: importID, done: make([]bool, numPrefixes)}
+	if prefix == "" {
+		return c, nil
+	}
+
+	next, err := prefixIndex(prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < next; i++ {
+		c.done[i] = true
+	}
+	c.next = next
+	return c, nil
+}
+
+// prefixIndex returns the position of a hash prefix in import order.
+func prefixIndex(prefix string) (int, error) {
+	i, err := strconv.ParseUint(prefix, 16, 20)
+	if err != nil || len(prefix) != 5 {
+		return 0, fmt.Errorf("invalid checkpoint %q", prefix)
+	}
+	return int(i), nil
+}
+
+// commit marks the ranges of prefixes as stored. A nil checkpoint ignores them.
+func (c *checkpoint) commit(prefixes ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, prefix := range prefixes {
+		if i, err := prefixIndex(prefix); err == nil {
+			c.done[i] = true
+		}
+	}
+	for c.next < numPrefixes && c.done[c.next] {
+		c.next++
+	}
+}
+
+// prefix returns the first prefix not committed yet, empty when all are.
+func (c *checkpoint) prefix() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.prefixLocked()
+}
+
+func (c *checkpoint) prefixLocked() string {
+	if c.next == numPrefixes {
+		return ""
+	}
+	return fmt.Sprintf("%05X", c.next)
+}
+
+// save records the checkpoint with the import, it is cleared once all ranges
+// are committed. Saves are serialized, so a later save never records an
+// earlier checkpoint. A nil checkpoint is not saved.
+func (c *checkpoint) save(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := c.prefixLocked()
+	_, err := c.db.ExecContext(ctx, `update `+c.layout.Qualified("hibp_import")+` set "checkpoint" = $2 where "import_id" = $1`,
+		c.importID, sql.NullString{String: prefix, Valid: prefix != ""})
+	return err
+}
+
+// interruptedImport is an API import that stopped before it finished and
+// can be resumed from its checkpoint.
+type interruptedImport struct {
+	ImportID   int    `db:"import_id"`
+	Source     string `db:"source"`
+	Strategy   string `db:"strategy"`
+	MinCount   int    `db:"min_count"`
+	FastLoad   bool   `db:"fast_load"`
+	Checkpoint string `db:"checkpoint"`
+}
+
+// loadInterrupted returns the latest unfinished import with a checkpoint.
+// Only the import lock holder may call it, so the import is not running.
+func loadInterrupted(ctx context.Context, db *sqlx.DB, layout dataset.Layout) (*interruptedImport, error) {
+	var imp interruptedImport
+	err := db.GetContext(ctx, &imp, `
+		select "import_id", "source", "strategy", "min_count", "fast_load", "checkpoint"
+		from `+layout.Qualified("hibp_import")+`
+		where "finished_at" is null and "checkpoint" is not null
+		order by "import_id" desc
+		limit 1`)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
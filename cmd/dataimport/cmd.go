@@ -1,22 +1,23 @@
 package dataimport
 
 import (
-	"bytes"
-	"encoding/csv"
+	"context"
+	"database/sql"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"os"
-	"strconv"
+	"os/signal"
+	"sort"
 	"strings"
 	"sync"
-	"time"
-	"bufio"
+	"syscall"
 
-	"github.com/spf13/cobra"
 	"github.com/jmoiron/sqlx"
-	"github.com/lib/pq"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/pwhash"
+	"github.com/leesalminen/hibp/upstream"
+	"github.com/leesalminen/hibp/watchlist"
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
 )
 
 // Command is the cobra command.
@@ -28,61 +29,94 @@ var Command = &cobra.Command{
 
 type commandConfig struct {
 	dsn        string
+	schema     string
 	noTruncate bool
 	batchSize  int
+	writers    int
+	source     string
+	sortBuffer int
+	tempDir    string
+	minCount   int
+	strategy   string
+	resume     bool
+	fastLoad   bool
+	replace    bool
+
+	watchlistReport  string
+	watchlistWebhook string
 }
 
 var config = new(commandConfig)
 
 func initFlags() {
-	Command.Flags().StringVar(&config.dsn, "dsn", "", "Database connection string")
+	Command.Flags().StringVar(&config.dsn, "dsn", "", "Database connection string, read from the DSN environment variable if not set")
+	Command.Flags().StringVar(&config.schema, "schema", dataset.DefaultSchema, "Schema of the instance, as passed to migrate")
 	Command.Flags().BoolVar(&config.noTruncate, "no-truncate", false, "If set, do not truncate the table before import")
 	Command.Flags().IntVar(&config.batchSize, "batch-size", 1000000, "Number of records to insert in one batch")
+	Command.Flags().IntVar(&config.writers, "writers", 4, "Number of concurrent database writers, each on a connection of its own")
+	Command.Flags().StringVar(&config.source, "source", sourceAPI, "Data source: api or wordlist:PATH")
+	Command.Flags().IntVar(&config.sortBuffer, "sort-buffer", 2000000, "Number of hashes sorted in memory before spilling to disk when importing a wordlist")
+	Command.Flags().StringVar(&config.strategy, "strategy", strategyTruncate, "Import strategy: truncate replaces the data, merge upserts it and keeps the per hash history, swap imports into new tables and replaces the old ones when done")
+	Command.Flags().StringVar(&config.watchlistReport, "watchlist-report", "", "Write the JSON report of newly pwned watchlist IDs to this file")
+	Command.Flags().StringVar(&config.watchlistWebhook, "watchlist-webhook", "", "POST the JSON report to this URL when watchlist IDs are newly pwned")
+	Command.Flags().IntVar(&config.minCount, "min-count", 0, "Skip hashes seen fewer times than this")
+	Command.Flags().StringVar(&config.tempDir, "temp-dir", os.TempDir(), "Directory for temporary files when importing a wordlist")
+	Command.Flags().BoolVar(&config.fastLoad, "fast-load", false, "Load into UNLOGGED partitions without prefix indexes, then build the indexes and make the partitions LOGGED. Requires --strategy=truncate or swap")
+	Command.Flags().BoolVar(&config.replace, "replace-data", false, "Allow a wordlist import with --strategy=truncate or swap to replace the imported HIBP data")
+	Command.Flags().BoolVar(&config.resume, "resume", false, "Resume the last interrupted API import from its checkpoint, with its source, strategy and min count")
 }
 
 func init() {
 	initFlags()
 }
 
-func fetchRange(prefix string) ([]string, error) {
-	url := fmt.Sprintf("https://api.pwnedpasswords.com/range/%s", prefix)
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	return strings.Split(string(body), "\r\n"), nil
-}
-
 type workItem struct {
 	prefix string
+	// version of the stored range, only set for incremental imports
+	version rangeVersion
 }
 
 type result struct {
-	prefix string
-	hashes []string
-	err    error
+	prefix  string
+	hashes  []string
+	version rangeVersion
+	// unchanged is set when the range did not change since version
+	unchanged bool
+	err       error
 }
 
 const (
 	numWorkers = 32  // Can be adjusted based on your needs
 	queueSize  = 100 // Buffer size for channels
-	
-	maxRetries     = 3           // Maximum number of retry attempts
-	initialBackoff = time.Second // Initial backoff duration
+)
+
+// Supported values of the --strategy flag.
+const (
+	strategyTruncate    = "truncate"
+	strategyMerge       = "merge"
+	strategySwap        = "swap"
+	strategyIncremental = "incremental"
+)
+
+// Exit statuses besides 0 for success and 1 for errors.
+const (
+	// exitLocked is the exit status when another import is running.
+	exitLocked = 3
+	// exitInterrupted is the exit status when the import stopped on SIGINT or SIGTERM.
+	exitInterrupted = 4
+)
+
+// Supported values of the --source flag.
+const (
+	sourceAPI            = "api"
+	sourceWordlistPrefix = "wordlist:"
 )
 
 func run(cmd *cobra.Command, _ []string) error {
+	if config.dsn == "" {
+		config.dsn = viper.GetString("dsn")
+	}
+
 	db, err := sqlx.Connect("postgres", config.dsn)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "error establishing database connection", err)
@@ -90,36 +124,339 @@ func run(cmd *cobra.Command, _ []string) error {
 	}
 	defer db.Close()
 
-	if !config.noTruncate {
-		_, sqlErr := db.Exec("truncate table hibp restart identity")
+	ctx := cmd.Context()
+
+	layout, err := dataset.LoadLayout(ctx, db, config.schema)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error loading the table layout", err)
+		os.Exit(1)
+	}
+
+	// only one instance may import into a schema at a time:
+	lock, err := dataset.LockImport(ctx, db, layout.Schema)
+	if err == dataset.ErrImportLocked {
+		fmt.Fprintln(os.Stderr, "another data-import is running")
+		os.Exit(exitLocked)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error locking import", err)
+		os.Exit(1)
+	}
+	defer lock.Close()
+
+	var resumed *interruptedImport
+	if config.resume {
+		resumed, err = loadInterrupted(ctx, db, layout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error loading interrupted import", err)
+			os.Exit(1)
+		}
+		if resumed == nil {
+			fmt.Fprintln(os.Stderr, "no interrupted import to resume")
+			os.Exit(1)
+		}
+		config.source, config.strategy, config.minCount, config.fastLoad = resumed.Source, resumed.Strategy, resumed.MinCount, resumed.FastLoad
+		fmt.Printf("Resuming import %d (%s, %s) from prefix %s\n", resumed.ImportID, resumed.Source, resumed.Strategy, resumed.Checkpoint)
+	}
+
+	wordlist := strings.TrimPrefix(config.source, sourceWordlistPrefix)
+	if config.source != sourceAPI && wordlist == config.source {
+		fmt.Fprintln(os.Stderr, "invalid --source", config.source, "expected api or wordlist:PATH")
+		os.Exit(1)
+	}
+
+	switch config.strategy {
+	case strategyTruncate, strategyMerge, strategySwap, strategyIncremental:
+	default:
+		fmt.Fprintln(os.Stderr, "invalid --strategy", config.strategy, "expected truncate, merge, swap or incremental")
+		os.Exit(1)
+	}
+	if config.strategy == strategyIncremental && config.source != sourceAPI {
+		fmt.Fprintln(os.Stderr, "--strategy=incremental requires --source=api")
+		os.Exit(1)
+	}
+	if config.writers < 1 {
+		fmt.Fprintln(os.Stderr, "--writers must be at least 1")
+		os.Exit(1)
+	}
+	if config.fastLoad && config.strategy != strategySwap && (config.strategy != strategyTruncate || config.noTruncate) {
+		fmt.Fprintln(os.Stderr, "--fast-load requires --strategy=truncate or swap, without --no-truncate")
+		os.Exit(1)
+	}
+	// a wordlist would replace the whole HIBP dataset, its rows are added
+	// next to the HIBP rows unless that is asked for
+	replacing := config.strategy == strategySwap || config.strategy == strategyTruncate && !config.noTruncate
+	if config.source != sourceAPI && replacing && !config.replace {
+		fmt.Fprintln(os.Stderr, "--source=wordlist with --strategy="+config.strategy, "replaces the HIBP data, use --no-truncate or --strategy=merge to add the wordlist or --replace-data to replace the data")
+		os.Exit(1)
+	}
+	truncate := config.strategy == strategyTruncate && !config.noTruncate && resumed == nil
+
+	var phases phaseTimer
+	phases.start("prepare")
+
+	tables := map[string]string{pwhash.TypeSHA1: layout.TableName(pwhash.TypeSHA1)}
+	if config.source != sourceAPI {
+		tables[pwhash.TypeNTLM] = layout.TableName(pwhash.TypeNTLM)
+	}
+
+	schema := layout.Schema
+	if config.strategy == strategySwap {
+		schema = layout.RefreshSchema()
+	}
+
+	if truncate {
+		var qualified []string
+		for _, table := range tableNames(tables) {
+			qualified = append(qualified, layout.Schema+"."+table)
+		}
+		_, sqlErr := db.Exec("truncate table " + strings.Join(qualified, ", ") + ", " + layout.Qualified("hibp_lazy_prefix") + ", " + layout.Qualified("hibp_range_version") + " restart identity")
 		if sqlErr != nil {
 			fmt.Fprintln(os.Stderr, "error truncating SQL table", sqlErr)
 			os.Exit(1)
 		}
 	}
 
+	if config.strategy == strategySwap && resumed == nil {
+		if err := dataset.CreateRefreshTables(ctx, db, layout, tableNames(tables)...); err != nil {
+			fmt.Fprintln(os.Stderr, "error creating refresh tables", err)
+			os.Exit(1)
+		}
+	}
+
+	if config.fastLoad && resumed == nil {
+		for _, table := range tableNames(tables) {
+			if err := dataset.PrepareFastLoad(ctx, db, layout, schema, table); err != nil {
+				fmt.Fprintln(os.Stderr, "error preparing fast load of", table, err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	var importID int
+	var cp *checkpoint
+	if resumed != nil {
+		importID = resumed.ImportID
+		if err := discardUncommitted(ctx, db, layout, schema, importID, resumed.Checkpoint); err != nil {
+			fmt.Fprintln(os.Stderr, "error discarding rows past the checkpoint", err)
+			os.Exit(1)
+		}
+	} else {
+		err = db.GetContext(ctx, &importID, `
+			insert into `+layout.Qualified("hibp_import")+` ("source", "strategy", "min_count", "truncated", "fast_load")
+			values ($1, $2, $3, $4, $5)
+			returning "import_id"`, config.source, config.strategy, config.minCount, truncate, config.fastLoad)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error recording import", err)
+			os.Exit(1)
+		}
+	}
+	if config.source == sourceAPI {
+		from := ""
+		if resumed != nil {
+			from = resumed.Checkpoint
+		}
+		if cp, err = newCheckpoint(db, layout, importID, from); err != nil {
+			fmt.Fprintln(os.Stderr, "error resuming import", err)
+			os.Exit(1)
+		}
+	}
+
+	// The first SIGINT or SIGTERM stops fetching and hashing, while the
+	// batches in flight are still written with ctx. A second signal kills
+	// the import, Postgres rolls back the open batch then.
+	interrupted, interrupt := context.WithCancel(ctx)
+	defer interrupt()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		signal.Stop(signals)
+		fmt.Fprintln(os.Stderr, "received", sig, "finishing the current batch, signal again to abort it")
+		interrupt()
+	}()
+
+	phases.start("load")
+	if config.source != sourceAPI {
+		err = importWordlist(ctx, interrupted, db, layout, wordlist, importID)
+	} else {
+		err = importAPI(ctx, interrupted, db, layout, importID, cp)
+	}
+	// an API import interrupted after its last range was committed is complete
+	if interrupted.Err() != nil && (cp == nil || cp.prefix() != "") {
+		if cp == nil {
+			// a wordlist has no checkpoint, the import has to run again
+			fmt.Fprintln(os.Stderr, "wordlist import interrupted, it can't be resumed: the batches written so far were kept, run it again to complete the tables")
+			os.Exit(exitInterrupted)
+		}
+		if err := cp.save(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, "error saving checkpoint", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "import interrupted at prefix", cp.prefix()+", continue it with data-import --resume")
+		os.Exit(exitInterrupted)
+	}
+	if err != nil {
+		// the failed ranges hold the checkpoint back, the import is left
+		// unfinished so that --resume imports them again
+		if cp != nil && cp.prefix() != "" {
+			if err := cp.save(ctx); err != nil {
+				fmt.Fprintln(os.Stderr, "error saving checkpoint", err)
+			}
+			fmt.Fprintln(os.Stderr, "error importing", config.source, err, "- retry from prefix", cp.prefix(), "with data-import --resume")
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "error importing", config.source, err)
+		os.Exit(1)
+	}
+	signal.Stop(signals)
+
+	// switching to LOGGED rewrites a partition together with its indexes,
+	// so the prefix indexes are built afterwards
+	if config.fastLoad {
+		phases.start("logged")
+		for _, table := range tableNames(tables) {
+			if err := dataset.SetLogged(ctx, db, layout, schema, table, config.writers); err != nil {
+				fmt.Fprintln(os.Stderr, "error making", table, "logged", err)
+				os.Exit(1)
+			}
+		}
+		phases.start("index")
+		for _, table := range tableNames(tables) {
+			if err := dataset.CreateIndexes(ctx, db, layout, schema, table, config.writers); err != nil {
+				fmt.Fprintln(os.Stderr, "error creating indexes of", table, err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if config.strategy == strategySwap {
+		phases.start("swap")
+		if err := dataset.Swap(ctx, db, layout, tables); err != nil {
+			fmt.Fprintln(os.Stderr, "error replacing tables", err)
+			os.Exit(1)
+		}
+		fmt.Println("Replaced", strings.Join(tableNames(tables), ", "), "with the imported data")
+	}
+
+	if _, err := db.ExecContext(ctx, `update `+layout.Qualified("hibp_import")+` set "finished_at" = now(), "checkpoint" = null where "import_id" = $1`, importID); err != nil {
+		fmt.Fprintln(os.Stderr, "error recording import", err)
+		os.Exit(1)
+	}
+
+	// re-check the watchlist against the new data:
+	phases.start("watchlist")
+	report, err := watchlist.Check(db, layout, importID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error checking watchlist", err)
+		os.Exit(1)
+	}
+	if err := watchlist.Publish(report, config.watchlistReport, config.watchlistWebhook); err != nil {
+		fmt.Fprintln(os.Stderr, "error publishing watchlist report", err)
+		os.Exit(1)
+	}
+	if report.Checked > 0 {
+		fmt.Printf("Checked %d watchlist hashes, %d newly pwned\n", report.Checked, len(report.NewlyPwned))
+	}
+	phases.report()
+
+	return nil
+}
+
+// tableNames returns the sorted tables of hashTypes.
+func tableNames(hashTypes map[string]string) []string {
+	names := make([]string, 0, len(hashTypes))
+	for _, table := range hashTypes {
+		names = append(names, table)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// discardUncommitted deletes the rows an interrupted truncate or swap import
+// stored for prefixes from checkpoint on, as they are imported again. Merges
+// and incremental imports are idempotent and keep them.
+func discardUncommitted(ctx context.Context, db *sqlx.DB, layout dataset.Layout, schema string, importID int, checkpoint string) error {
+	if config.strategy != strategyTruncate && config.strategy != strategySwap {
+		return nil
+	}
+	_, err := db.ExecContext(ctx, `
+		delete from `+schema+`.`+layout.TableName(pwhash.TypeSHA1)+`
+		where "partition_prefix" >= $1 and "prefix" >= $2 and "first_seen_import" = $3`,
+		layout.Key(checkpoint), checkpoint, importID)
+	return err
+}
+
+// importAPI imports the ranges from the HIBP API that cp does not hold as
+// committed. Dispatching stops when interrupted is done, the fetched ranges
+// are still written with ctx. It fails when any range failed to fetch or
+// write, so that a partial import is never swapped in or marked finished.
+func importAPI(ctx, interrupted context.Context, db *sqlx.DB, layout dataset.Layout, importID int, cp *checkpoint) error {
+	target, err := newImportTarget(db, layout, pwhash.TypeSHA1, importID)
+	if err != nil {
+		return err
+	}
+
 	// Create channels for work distribution and results
 	work := make(chan workItem, queueSize)
 	results := make(chan result, queueSize)
-	done := make(chan bool)
+	done := make(chan int)
 
 	// Start worker pool
+	client := upstream.New(upstream.DefaultBaseURL, 0)
 	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
-		go worker(work, results, &wg)
+		go worker(interrupted, client, work, results, &wg)
 	}
 
 	// Start result processor
-	go processResults(db, results, done)
+	incremental := config.strategy == strategyIncremental
+	if incremental {
+		go processChanged(ctx, db, target, cp, results, done)
+	} else {
+		go processResults(ctx, db, target, cp, results, done)
+	}
 
-	// Generate and send work items
+	// Generate and send work items. The partition keys of all writers are
+	// fetched side by side, so that the writers are kept busy; the stored
+	// versions are loaded for those keys only, to bound memory.
+	start := cp.next
+	keyBits := 4 * (5 - layout.KeyLength())
 	go func() {
-		for i := 0; i < 16*16*16*16*16; i++ {
-			prefix := fmt.Sprintf("%05X", i)
-			work <- workItem{prefix: prefix}
+		defer close(work)
+		for first := start >> keyBits; first < layout.Partitions; first += config.writers {
+			last := first + config.writers - 1
+			if last >= layout.Partitions {
+				last = layout.Partitions - 1
+			}
+
+			versions := make(map[string]rangeVersion)
+			for i := first; incremental && i <= last; i++ {
+				key := fmt.Sprintf("%0*X", layout.KeyLength(), i)
+				stored, err := loadVersions(db, layout, pwhash.TypeSHA1, key)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, "error loading range versions, fetching prefixes", key+"* in full:", err)
+				}
+				for prefix, version := range stored {
+					versions[prefix] = version
+				}
+			}
+
+			for j := 0; j < 1<<keyBits; j++ {
+				for i := first; i <= last; i++ {
+					if i<<keyBits|j < start {
+						continue
+					}
+					prefix := fmt.Sprintf("%05X", i<<keyBits|j)
+					select {
+					case work <- workItem{prefix: prefix, version: versions[prefix]}:
+					case <-interrupted.Done():
+						return
+					}
+				}
+			}
 		}
-		close(work)
 	}()
 
 	// Wait for all workers to complete
@@ -127,173 +464,146 @@ func run(cmd *cobra.Command, _ []string) error {
 	close(results)
 
 	// Wait for result processor to complete
-	<-done
+	if failed := <-done; failed > 0 {
+		return fmt.Errorf("%d ranges failed", failed)
+	}
 
 	return nil
 }
 
-// Add retry helper function
-func fetchRangeWithRetry(prefix string) ([]string, error) {
-	var lastErr error
-	backoff := initialBackoff
-
-	for attempt := 0; attempt <= maxRetries; attempt++ {
-		if attempt > 0 {
-			time.Sleep(backoff)
-			backoff *= 2 // Exponential backoff
-		}
-
-		hashes, err := fetchRange(prefix)
-		if err == nil {
-			return hashes, nil
-		}
+// worker fetches the ranges of work until it is closed. Once ctx is done the
+// remaining items are dropped, their ranges are left to a resumed import.
+func worker(ctx context.Context, client *upstream.Client, work <-chan workItem, results chan<- result, wg *sync.WaitGroup) {
+	defer wg.Done()
 
-		lastErr = err
-		
-		// Check for specific errors to retry
-		if _, ok := err.(*url.Error); ok {
-			// Network errors should be retried
-			continue
-		} else if strings.Contains(err.Error(), "GOAWAY") {
-			// Retry on GOAWAY errors
+	for item := range work {
+		if ctx.Err() != nil {
 			continue
-		} else if strings.Contains(err.Error(), "429") {
-			// Rate limit errors should be retried
+		}
+		r, err := client.FetchRangeIfChangedWithRetry(ctx, pwhash.TypeSHA1, item.prefix[:5], item.version.ETag, item.version.LastModified)
+		if ctx.Err() != nil {
 			continue
-		} else if strings.Contains(err.Error(), "500") || 
-			  strings.Contains(err.Error(), "502") || 
-			  strings.Contains(err.Error(), "503") || 
-			  strings.Contains(err.Error(), "504") {
-			// Server errors should be retried
+		}
+		if err != nil {
+			results <- result{prefix: item.prefix, err: err}
 			continue
 		}
-		
-		// Don't retry other types of errors
-		return nil, err
-	}
-
-	return nil, fmt.Errorf("after %d attempts, last error: %v", maxRetries+1, lastErr)
-}
-
-// Modify the worker function to use retry logic
-func worker(work <-chan workItem, results chan<- result, wg *sync.WaitGroup) {
-	defer wg.Done()
-
-	for item := range work {
-		hashes, err := fetchRangeWithRetry(item.prefix[:5])
 		results <- result{
-			prefix: item.prefix,
-			hashes: hashes,
-			err:    err,
+			prefix:    item.prefix,
+			hashes:    r.Lines,
+			version:   rangeVersion{ETag: r.ETag, LastModified: r.LastModified},
+			unchanged: r.NotModified,
 		}
 	}
 }
 
-// Add new result processor function
-func processResults(db *sqlx.DB, results <-chan result, done chan<- bool) {
-	var buffer bytes.Buffer
-	csvWriter := csv.NewWriter(&buffer)
-	csvWriter.Comma = '\t'
-
-	currentLine := 0
-	batchCount := 0
-
-	for res := range results {
-		if res.err != nil {
-			fmt.Fprintf(os.Stderr, "error fetching range for prefix %s: %v\n", res.prefix, res.err)
-			continue
-		}
-
-		for _, line := range res.hashes {
-			currentLine++
-			
-			parts := strings.Split(strings.TrimSpace(line), ":")
-			if len(parts) != 2 {
-				fmt.Fprintln(os.Stderr, "line", currentLine, "skipped, split by ':' did not result in 2 items")
-				continue
-			}
-
-			suffix := parts[0]
-			count, err := strconv.Atoi(parts[1])
-			if err != nil {
-				fmt.Fprintln(os.Stderr, "line", currentLine, "skipped, error converting count", parts[1], "as integer", err)
-				continue
-			}
-
-			csvWriter.Write([]string{
-					res.prefix[0:2],
-					res.prefix,
-					suffix,
-					strconv.Itoa(count),
-			})
-
-			batchCount++
+// importTarget is the table results are loaded into and the hashes excluded from it.
+type importTarget struct {
+	layout   dataset.Layout
+	hashType string
+	schema   string
+	table    string
+	// source of the import, written to the rows and scoping the merge
+	source   string
+	importID int
+	merge    bool
+	excluded map[string]bool
+	// partition of every prefix with hash partitioning, by prefix index
+	partitions []uint16
+}
 
-			if batchCount >= config.batchSize {
-				if err := flushBatch(db, &buffer, csvWriter); err != nil {
-					fmt.Fprintln(os.Stderr, "error flushing batch:", err)
-					continue
-				}
-				batchCount = 0
-				fmt.Printf("Imported %d lines\n", currentLine)
-			}
-		}
+// partition returns the partition the rows of prefix are stored in.
+func (t importTarget) partition(prefix string) (int, error) {
+	if t.partitions == nil {
+		return t.layout.KeyIndex(prefix)
 	}
-
-	// Flush any remaining records
-	if batchCount > 0 {
-		if err := flushBatch(db, &buffer, csvWriter); err != nil {
-			fmt.Fprintln(os.Stderr, "error flushing final batch:", err)
-		}
+	i, err := prefixIndex(prefix)
+	if err != nil {
+		return 0, err
 	}
-
-	done <- true
+	return int(t.partitions[i]), nil
 }
 
-// Add new helper function for batch flushing
-func flushBatch(db *sqlx.DB, buffer *bytes.Buffer, csvWriter *csv.Writer) error {
-	csvWriter.Flush()
-	if csvWriter.Error() != nil {
-		return csvWriter.Error()
+// newImportTarget loads the exclusion list of hashType and, with hash
+// partitioning, the partitions of the prefixes.
+func newImportTarget(db *sqlx.DB, layout dataset.Layout, hashType string, importID int) (importTarget, error) {
+	target := importTarget{
+		layout:   layout,
+		hashType: hashType,
+		schema:   layout.Schema,
+		table:    layout.TableName(hashType),
+		source:   config.source,
+		importID: importID,
+		merge:    config.strategy == strategyMerge,
+		excluded: make(map[string]bool),
 	}
-
-	tx, err := db.Begin()
-	if err != nil {
-		return err
+	if config.strategy == strategySwap {
+		target.schema = layout.RefreshSchema()
 	}
 
-	stmt, err := tx.Prepare(pq.CopyIn("hibp", "partition_prefix", "prefix", "hash", "count"))
+	var hashes []string
+	err := db.Select(&hashes, `select "prefix" || "hash" from `+layout.Qualified("hibp_exclusion")+` where "hash_type" = $1`, hashType)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return target, err
+	}
+	for _, hash := range hashes {
+		target.excluded[hash] = true
 	}
-	defer stmt.Close()
 
-	// Read the buffer line by line and execute the copy
-	scanner := bufio.NewScanner(buffer)
-	for scanner.Scan() {
-		fields := strings.Split(scanner.Text(), "\t")
-		if len(fields) != 4 {
-			continue
-		}
-		
-		_, err = stmt.Exec(fields[0], fields[1], fields[2], fields[3])
-		if err != nil {
-			tx.Rollback()
-			return err
+	if layout.Method == dataset.PartitionHash {
+		if target.partitions, err = dataset.HashPartitions(context.Background(), db, layout); err != nil {
+			return target, err
 		}
 	}
 
-	_, err = stmt.Exec()
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
+	return target, nil
+}
 
-	if err := tx.Commit(); err != nil {
+// mergeStaging upserts the staged batch into the rows of the target source.
+// The rows of other sources are left alone, a wordlist never overwrites the
+// HIBP count of a hash, their counts are summed when served. Changed counts
+// are recorded with the previous count and the import that changed them,
+// new hashes with the import that first saw them. Both are logged in
+// hibp_change, which keeps the changes of every import for hibp diff.
+func mergeStaging(ctx context.Context, tx *sql.Tx, target importTarget) error {
+	_, err := tx.ExecContext(ctx, `
+		with changed as (
+			update `+target.schema+`.`+target.table+` h set
+				"previous_count" = h."count",
+				"count" = s."count",
+				"count_changed_at" = now(),
+				"count_changed_import" = $1
+			from hibp_staging s
+			where h."partition_prefix" = s."partition_prefix"
+			and h."prefix" = s."prefix"
+			and h."hash" = s."hash"
+			and h."source" = s."source"
+			and h."count" <> s."count"
+			returning h."prefix", h."hash", h."source", h."previous_count", h."count"
+		)
+		insert into `+target.layout.Qualified("hibp_change")+` ("import_id", "hash_type", "prefix", "hash", "source", "previous_count", "count")
+		select $1, $2, "prefix", "hash", "source", "previous_count", "count" from changed
+		on conflict do nothing`, target.importID, target.hashType)
+	if err != nil {
 		return err
 	}
 
-	buffer.Reset()
-	return nil
+	_, err = tx.ExecContext(ctx, `
+		with added as (
+			insert into `+target.schema+`.`+target.table+` ("partition_prefix", "prefix", "hash", "count", "first_seen_import", "source")
+			select s."partition_prefix", s."prefix", s."hash", s."count", s."first_seen_import", s."source"
+			from hibp_staging s
+			where not exists (
+				select 1 from `+target.schema+`.`+target.table+` h
+				where h."partition_prefix" = s."partition_prefix"
+				and h."prefix" = s."prefix"
+				and h."hash" = s."hash"
+				and h."source" = s."source"
+			)
+			returning "prefix", "hash", "source", "count"
+		)
+		insert into `+target.layout.Qualified("hibp_change")+` ("import_id", "hash_type", "prefix", "hash", "source", "count")
+		select $1, $2, "prefix", "hash", "source", "count" from added
+		on conflict do nothing`, target.importID, target.hashType)
+	return err
 }
@@ -0,0 +1,188 @@
+package dataimport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/pwhash"
+	"github.com/leesalminen/hibp/upstream"
+	"github.com/lib/pq"
+)
+
+// rangeVersion holds the upstream validators of a stored range.
+type rangeVersion struct {
+	ETag         string `db:"etag"`
+	LastModified string `db:"last_modified"`
+}
+
+// loadVersions returns the stored versions of the hashType ranges starting
+// with partition, by prefix. Ranges imported with another --min-count
+// are left out, so that they are fetched and filtered again.
+func loadVersions(db *sqlx.DB, layout dataset.Layout, hashType, partition string) (map[string]rangeVersion, error) {
+	var rows []struct {
+		Prefix string `db:"prefix"`
+		rangeVersion
+	}
+	err := db.Select(&rows, `
+		select "prefix", "etag", "last_modified"
+		from `+layout.Qualified("hibp_range_version")+`
+		where "hash_type" = $1 and "prefix" like $2 and "min_count" = $3`,
+		hashType, partition+"%", config.minCount)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]rangeVersion, len(rows))
+	for _, row := range rows {
+		versions[row.Prefix] = row.rangeVersion
+	}
+	return versions, nil
+}
+
+// processChanged replaces every changed range in a transaction of its own
+// and skips the unchanged ones, with --writers ranges replaced at a time.
+// A range that fails keeps its stored rows and version, so it is fetched
+// again by the next import. The checked ranges are committed to cp, which
+// is saved every 4096 ranges. The number of failed ranges is sent to done.
+func processChanged(ctx context.Context, db *sqlx.DB, target importTarget, cp *checkpoint, results <-chan result, done chan<- int) {
+	var mu sync.Mutex
+	var checked, changed, failed int
+
+	var wg sync.WaitGroup
+	for i := 0; i < config.writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for res := range results {
+				ok := res.err == nil
+				if res.err != nil {
+					fmt.Fprintf(os.Stderr, "error fetching range for prefix %s: %v\n", res.prefix, res.err)
+				} else if !res.unchanged {
+					if err := replaceRange(ctx, db, target, res); err != nil {
+						fmt.Fprintf(os.Stderr, "error replacing range %s: %v\n", res.prefix, err)
+						ok = false
+					}
+				}
+				if ok {
+					cp.commit(res.prefix)
+				}
+
+				mu.Lock()
+				checked++
+				if !ok {
+					failed++
+				} else if !res.unchanged {
+					changed++
+				}
+				if checked%65536 == 0 {
+					fmt.Printf("Checked %d ranges, %d changed\n", checked, changed)
+				}
+				save := checked%4096 == 0
+				mu.Unlock()
+
+				if save {
+					if err := cp.save(ctx); err != nil {
+						fmt.Fprintln(os.Stderr, "error saving checkpoint:", err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := cp.save(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "error saving checkpoint:", err)
+	}
+	fmt.Printf("Checked %d ranges, %d changed, %d unchanged, %d failed\n", checked, changed, checked-changed-failed, failed)
+	done <- failed
+}
+
+// replaceRange merges the fetched rows of a range through the staging
+// table, so that the hashes keep their history, deletes the HIBP hashes
+// missing from the fetched range and records its version. The rows of
+// wordlists in the range are kept.
+func replaceRange(ctx context.Context, db *sqlx.DB, target importTarget, res result) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := createStaging(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("hibp_staging", "partition_prefix", "prefix", "hash", "count", "first_seen_import", "source"))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, line := range res.hashes {
+		suffix, count, err := upstream.ParseLine(line)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "line of range", res.prefix, "skipped,", err)
+			continue
+		}
+		if count < config.minCount || target.excluded[res.prefix+suffix] {
+			continue
+		}
+
+		if _, err := stmt.ExecContext(ctx, target.layout.Key(res.prefix), res.prefix, suffix, count, target.importID, target.source); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	stmt.Close()
+
+	if err := mergeStaging(ctx, tx, target); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		delete from `+target.schema+`.`+target.table+` h
+		where h."partition_prefix" = $1 and h."prefix" = $2 and h."source" = $3
+		and not exists (
+			select 1 from hibp_staging s
+			where s."prefix" = h."prefix" and s."hash" = h."hash"
+		)`, target.layout.Key(res.prefix), res.prefix, target.source)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		insert into `+target.layout.Qualified("hibp_range_version")+` ("hash_type", "prefix", "etag", "last_modified", "min_count", "import_id")
+		values ($1, $2, $3, $4, $5, $6)
+		on conflict ("hash_type", "prefix") do update set
+			"etag" = excluded."etag",
+			"last_modified" = excluded."last_modified",
+			"min_count" = excluded."min_count",
+			"import_id" = excluded."import_id",
+			"changed_at" = now()`,
+		pwhash.TypeSHA1, res.prefix, res.version.ETag, res.version.LastModified, config.minCount, target.importID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// the range is complete now, the upstream fallback no longer owns it
+	_, err = tx.ExecContext(ctx, `delete from `+target.layout.Qualified("hibp_lazy_prefix")+` where "hash_type" = $1 and "prefix" = $2`, pwhash.TypeSHA1, res.prefix)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
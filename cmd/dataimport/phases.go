@@ -0,0 +1,38 @@
+package dataimport
+
+import (
+	"fmt"
+	"time"
+)
+
+// phaseTimer measures how long the phases of an import take.
+type phaseTimer struct {
+	names     []string
+	durations []time.Duration
+	started   time.Time
+}
+
+// start ends the current phase, if any, and starts the named one.
+func (t *phaseTimer) start(name string) {
+	t.stop()
+	t.names = append(t.names, name)
+	t.started = time.Now()
+}
+
+// stop ends the current phase.
+func (t *phaseTimer) stop() {
+	if len(t.durations) < len(t.names) {
+		t.durations = append(t.durations, time.Since(t.started))
+	}
+}
+
+// report ends the current phase and prints the duration of every phase.
+func (t *phaseTimer) report() {
+	t.stop()
+	var total time.Duration
+	for i, name := range t.names {
+		fmt.Printf("%-10s %s\n", name, t.durations[i].Round(time.Millisecond))
+		total += t.durations[i]
+	}
+	fmt.Printf("%-10s %s\n", "total", total.Round(time.Millisecond))
+}
@@ -0,0 +1,287 @@
+package dataimport
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/pwhash"
+)
+
+// maxLineLength is the longest wordlist line accepted.
+const maxLineLength = 1024 * 1024
+
+// importWordlist hashes every line of the plaintext wordlist at path to SHA-1
+// and NTLM, counts the occurrences of each hash and loads the results into
+// the hibp and hibp_ntlm tables. The hashes are sorted externally, so memory
+// use is bounded by --sort-buffer regardless of the wordlist size. Hashing
+// and loading stop when interrupted is done, the batches are written with ctx.
+func importWordlist(ctx, interrupted context.Context, db *sqlx.DB, layout dataset.Layout, path string, importID int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sha1Sorter, err := newExternalSorter(config.tempDir, config.sortBuffer)
+	if err != nil {
+		return err
+	}
+	defer sha1Sorter.cleanup()
+
+	ntlmSorter, err := newExternalSorter(config.tempDir, config.sortBuffer)
+	if err != nil {
+		return err
+	}
+	defer ntlmSorter.cleanup()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+
+	lines := 0
+	for scanner.Scan() {
+		if err := interrupted.Err(); err != nil {
+			return err
+		}
+		word := strings.TrimSuffix(scanner.Text(), "\r")
+		if word == "" {
+			continue
+		}
+
+		if err := sha1Sorter.add(pwhash.SHA1(word)); err != nil {
+			return err
+		}
+		if err := ntlmSorter.add(pwhash.NTLM(word)); err != nil {
+			return err
+		}
+
+		lines++
+		if lines%config.batchSize == 0 {
+			fmt.Printf("Hashed %d lines\n", lines)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	fmt.Printf("Hashed %d lines\n", lines)
+
+	if err := loadSorted(ctx, interrupted, db, layout, pwhash.TypeSHA1, sha1Sorter, importID); err != nil {
+		return err
+	}
+	return loadSorted(ctx, interrupted, db, layout, pwhash.TypeNTLM, ntlmSorter, importID)
+}
+
+// loadSorted groups the sorted hashes by prefix and feeds them to processResults
+// until interrupted is done. It fails when any range failed to write.
+func loadSorted(ctx, interrupted context.Context, db *sqlx.DB, layout dataset.Layout, hashType string, sorter *externalSorter, importID int) error {
+	target, err := newImportTarget(db, layout, hashType, importID)
+	if err != nil {
+		return err
+	}
+
+	results := make(chan result, queueSize)
+	done := make(chan int)
+	go processResults(ctx, db, target, nil, results, done)
+
+	var prefix string
+	var hashes []string
+
+	err = sorter.merge(func(hash string, count int) error {
+		hashPrefix, suffix := pwhash.Split(hash)
+		if hashPrefix != prefix && len(hashes) > 0 {
+			if err := interrupted.Err(); err != nil {
+				return err
+			}
+			results <- result{prefix: prefix, hashes: hashes}
+			hashes = nil
+		}
+		prefix = hashPrefix
+		hashes = append(hashes, fmt.Sprintf("%s:%d", suffix, count))
+		return nil
+	})
+	if err == nil && len(hashes) > 0 {
+		results <- result{prefix: prefix, hashes: hashes}
+	}
+
+	close(results)
+	if failed := <-done; failed > 0 && err == nil {
+		err = fmt.Errorf("%d ranges failed", failed)
+	}
+
+	return err
+}
+
+// externalSorter sorts more strings than fit in memory by writing sorted
+// chunks to temporary files and merging them.
+type externalSorter struct {
+	dir    string
+	limit  int
+	buffer []string
+	chunks []string
+}
+
+func newExternalSorter(tempDir string, limit int) (*externalSorter, error) {
+	dir, err := os.MkdirTemp(tempDir, "hibp-wordlist-")
+	if err != nil {
+		return nil, err
+	}
+
+	return &externalSorter{
+		dir:    dir,
+		limit:  limit,
+		buffer: make([]string, 0, limit),
+	}, nil
+}
+
+func (s *externalSorter) add(value string) error {
+	s.buffer = append(s.buffer, value)
+	if len(s.buffer) >= s.limit {
+		return s.spill()
+	}
+	return nil
+}
+
+// spill writes the sorted buffer to a new chunk file.
+func (s *externalSorter) spill() error {
+	sort.Strings(s.buffer)
+
+	path := filepath.Join(s.dir, fmt.Sprintf("chunk-%06d", len(s.chunks)))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for _, value := range s.buffer {
+		w.WriteString(value)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	s.chunks = append(s.chunks, path)
+	s.buffer = s.buffer[:0]
+	return nil
+}
+
+// merge calls emit for every distinct value in sorted order,
+// together with the number of times it was added. It stops at the
+// first error returned by emit.
+func (s *externalSorter) merge(emit func(value string, count int) error) error {
+	if len(s.chunks) == 0 {
+		sort.Strings(s.buffer)
+		return emitCounted(s.buffer, emit)
+	}
+
+	if len(s.buffer) > 0 {
+		if err := s.spill(); err != nil {
+			return err
+		}
+	}
+
+	h := make(chunkHeap, 0, len(s.chunks))
+	for _, path := range s.chunks {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		c := &chunkReader{scanner: bufio.NewScanner(f)}
+		if c.next() {
+			h = append(h, c)
+		} else if err := c.scanner.Err(); err != nil {
+			return err
+		}
+	}
+	heap.Init(&h)
+
+	var current string
+	count := 0
+	for h.Len() > 0 {
+		c := h[0]
+		if count > 0 && c.value != current {
+			if err := emit(current, count); err != nil {
+				return err
+			}
+			count = 0
+		}
+		current = c.value
+		count++
+
+		if c.next() {
+			heap.Fix(&h, 0)
+		} else {
+			if err := c.scanner.Err(); err != nil {
+				return err
+			}
+			heap.Pop(&h)
+		}
+	}
+	if count > 0 {
+		return emit(current, count)
+	}
+
+	return nil
+}
+
+// cleanup removes the temporary chunk files.
+func (s *externalSorter) cleanup() {
+	os.RemoveAll(s.dir)
+}
+
+// emitCounted calls emit for every distinct value of the sorted slice
+// until it returns an error.
+func emitCounted(sorted []string, emit func(value string, count int) error) error {
+	for i := 0; i < len(sorted); {
+		j := i + 1
+		for j < len(sorted) && sorted[j] == sorted[i] {
+			j++
+		}
+		if err := emit(sorted[i], j-i); err != nil {
+			return err
+		}
+		i = j
+	}
+	return nil
+}
+
+// chunkReader reads the values of one sorted chunk file.
+type chunkReader struct {
+	scanner *bufio.Scanner
+	value   string
+}
+
+func (c *chunkReader) next() bool {
+	if !c.scanner.Scan() {
+		return false
+	}
+	c.value = c.scanner.Text()
+	return true
+}
+
+// chunkHeap orders chunk readers by their current value.
+type chunkHeap []*chunkReader
+
+func (h chunkHeap) Len() int            { return len(h) }
+func (h chunkHeap) Less(i, j int) bool  { return h[i].value < h[j].value }
+func (h chunkHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *chunkHeap) Push(x interface{}) { *h = append(*h, x.(*chunkReader)) }
+func (h *chunkHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,233 @@
+package dataimport
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/upstream"
+	"github.com/lib/pq"
+)
+
+// processResults loads the results with --writers COPY writers on
+// connections of their own. Every writer owns the partitions whose number
+// modulo the writer count is its index, so a partition is written by
+// exactly one writer. Rows are streamed to Postgres as they are parsed, memory use
+// is bounded by the queued ranges and does not grow with --batch-size.
+// Committed ranges are committed to cp, a nil cp is ignored. The number of
+// ranges that failed to fetch or write is sent to done.
+func processResults(ctx context.Context, db *sqlx.DB, target importTarget, cp *checkpoint, results <-chan result, done chan<- int) {
+	var imported, failed int64
+	var wg sync.WaitGroup
+
+	writers := make([]*copyWriter, config.writers)
+	for i := range writers {
+		writers[i] = &copyWriter{
+			db:       db,
+			target:   target,
+			cp:       cp,
+			ranges:   make(chan result, queueSize/len(writers)+1),
+			imported: &imported,
+			failed:   &failed,
+		}
+		wg.Add(1)
+		go writers[i].run(ctx, &wg)
+	}
+
+	for res := range results {
+		if res.err != nil {
+			fmt.Fprintf(os.Stderr, "error fetching range for prefix %s: %v\n", res.prefix, res.err)
+			atomic.AddInt64(&failed, 1)
+			continue
+		}
+		partition, err := target.partition(res.prefix)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "range", res.prefix, "skipped,", err)
+			atomic.AddInt64(&failed, 1)
+			continue
+		}
+		writers[partition%len(writers)].ranges <- res
+	}
+
+	for _, w := range writers {
+		close(w.ranges)
+	}
+	wg.Wait()
+
+	done <- int(atomic.LoadInt64(&failed))
+}
+
+// copyWriter copies the rows of its ranges into the target table. A batch
+// is one transaction holding whole ranges, it is committed once it has
+// --batch-size rows or the ranges run out.
+type copyWriter struct {
+	db       *sqlx.DB
+	target   importTarget
+	cp       *checkpoint
+	ranges   chan result
+	imported *int64
+	// ranges lost with failed batches, shared by the writers
+	failed *int64
+
+	tx   *sql.Tx
+	stmt *sql.Stmt
+	rows int
+	// ranges in the open batch
+	pending []string
+}
+
+// run writes the ranges until the channel is closed and commits the last batch.
+// A failed batch is rolled back, its ranges are counted as failed and left
+// out of the checkpoint.
+func (w *copyWriter) run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for res := range w.ranges {
+		if err := w.write(ctx, res); err != nil {
+			fmt.Fprintln(os.Stderr, "error writing range", res.prefix, "batch rolled back:", err)
+			atomic.AddInt64(w.failed, int64(len(w.pending)+1))
+			w.rollback()
+			continue
+		}
+		if w.rows >= config.batchSize {
+			w.flush(ctx, "error flushing batch:")
+		}
+	}
+
+	w.flush(ctx, "error flushing final batch:")
+}
+
+// flush commits the open batch and counts its ranges as failed when that fails.
+func (w *copyWriter) flush(ctx context.Context, message string) {
+	pending := len(w.pending)
+	if err := w.commit(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, message, err)
+		atomic.AddInt64(w.failed, int64(pending))
+	}
+}
+
+// write copies the rows of a range into the open batch, beginning a new one if needed.
+func (w *copyWriter) write(ctx context.Context, res result) error {
+	if w.tx == nil {
+		if err := w.begin(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, line := range res.hashes {
+		suffix, count, err := upstream.ParseLine(line)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "line of range", res.prefix, "skipped,", err)
+			continue
+		}
+		if count < config.minCount || w.target.excluded[res.prefix+suffix] {
+			continue
+		}
+
+		if _, err := w.stmt.ExecContext(ctx, w.target.layout.Key(res.prefix), res.prefix, suffix, count, w.target.importID, w.target.source); err != nil {
+			return err
+		}
+		w.rows++
+	}
+
+	w.pending = append(w.pending, res.prefix)
+	return nil
+}
+
+// createStaging creates the staging table merged by mergeStaging, it is
+// dropped when tx ends.
+func createStaging(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		create temporary table hibp_staging (
+			partition_prefix varchar(3) NOT NULL,
+			prefix varchar(5) NOT NULL,
+			hash varchar(40) NOT NULL,
+			count integer NOT NULL,
+			first_seen_import integer NOT NULL,
+			source text NOT NULL
+		) on commit drop`)
+	return err
+}
+
+// begin starts a batch. The merge strategy copies into a staging table
+// and upserts from there when the batch is committed.
+func (w *copyWriter) begin(ctx context.Context) error {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	copyIn := pq.CopyInSchema(w.target.schema, w.target.table, "partition_prefix", "prefix", "hash", "count", "first_seen_import", "source")
+	if w.target.merge {
+		copyIn = pq.CopyIn("hibp_staging", "partition_prefix", "prefix", "hash", "count", "first_seen_import", "source")
+		if err := createStaging(ctx, tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx, copyIn)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	w.tx, w.stmt = tx, stmt
+	return nil
+}
+
+// commit ends the COPY and commits the open batch, if any. The batch is
+// rolled back when ctx is done before it commits.
+func (w *copyWriter) commit(ctx context.Context) error {
+	if w.tx == nil {
+		return nil
+	}
+
+	if _, err := w.stmt.ExecContext(ctx); err != nil {
+		w.rollback()
+		return err
+	}
+	w.stmt.Close()
+
+	if w.target.merge {
+		if err := mergeStaging(ctx, w.tx, w.target); err != nil {
+			w.rollback()
+			return err
+		}
+	}
+
+	if err := w.tx.Commit(); err != nil {
+		w.reset()
+		return err
+	}
+
+	total := atomic.AddInt64(w.imported, int64(w.rows))
+	fmt.Printf("Imported %d rows\n", total)
+
+	w.cp.commit(w.pending...)
+	w.reset()
+	if err := w.cp.save(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "error saving checkpoint:", err)
+	}
+	return nil
+}
+
+// rollback discards the open batch.
+func (w *copyWriter) rollback() {
+	if w.tx == nil {
+		return
+	}
+	w.stmt.Close()
+	w.tx.Rollback()
+	w.reset()
+}
+
+func (w *copyWriter) reset() {
+	w.tx, w.stmt = nil, nil
+	w.rows = 0
+	w.pending = nil
+}
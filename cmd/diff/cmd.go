@@ -0,0 +1,194 @@
+package diff
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/pwhash"
+	"github.com/spf13/cobra"
+
+	"github.com/lib/pq"
+)
+
+// Command is the cobra command.
+var Command = &cobra.Command{
+	Use:   "diff",
+	Short: "List hashes added or whose count rose between two imports",
+	Long: `List hashes added or whose count rose between two imports.
+
+The changes are read from the change log that imports using --strategy=merge
+or incremental keep in hibp_change. Only the rows of --source are compared,
+the HIBP data by default or a wordlist imported with --source=wordlist:PATH.
+Imports with another strategy don't log their changes, a truncate for instance
+reloads every hash as new, so a diff across one of the same source, or across
+one that replaced the data, is refused. The report is written as CSV with the
+columns change, hash, previous_count and count.`,
+	RunE: run,
+}
+
+type commandConfig struct {
+	dsn      string
+	schema   string
+	source   string
+	from     int
+	to       int
+	hashType string
+}
+
+var config = new(commandConfig)
+
+func initFlags() {
+	Command.Flags().StringVar(&config.dsn, "dsn", "", "Database connection string")
+	Command.Flags().StringVar(&config.schema, "schema", dataset.DefaultSchema, "Schema of the instance, as passed to migrate")
+	Command.Flags().StringVar(&config.source, "source", "api", "Source whose rows are compared, api or the wordlist:PATH passed to data-import")
+	Command.Flags().IntVar(&config.from, "from", 0, "Import ID to compare from, defaults to the import of --source before --to")
+	Command.Flags().IntVar(&config.to, "to", 0, "Import ID to compare to, defaults to the latest finished import of --source")
+	Command.Flags().StringVar(&config.hashType, "type", pwhash.TypeSHA1, "Hash type: sha1 or ntlm")
+}
+
+func init() {
+	initFlags()
+}
+
+// Values of the change column.
+const (
+	changeAdded = "added"
+	changeRose  = "rose"
+)
+
+// loggedStrategies are the import strategies that log their changes in hibp_change.
+var loggedStrategies = []string{"merge", "incremental"}
+
+type changedRow struct {
+	Change        string        `db:"change"`
+	Prefix        string        `db:"prefix"`
+	Hash          string        `db:"hash"`
+	PreviousCount sql.NullInt64 `db:"previous_count"`
+	Count         int           `db:"count"`
+}
+
+func run(cmd *cobra.Command, _ []string) error {
+	switch config.hashType {
+	case pwhash.TypeSHA1, pwhash.TypeNTLM:
+	default:
+		fmt.Fprintln(os.Stderr, "invalid --type", config.hashType, "expected sha1 or ntlm")
+		os.Exit(1)
+	}
+
+	db, err := sqlx.Connect("postgres", config.dsn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error establishing database connection", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	layout, err := dataset.LoadLayout(cmd.Context(), db, config.schema)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error loading the table layout", err)
+		os.Exit(1)
+	}
+
+	if config.to == 0 {
+		err := db.Get(&config.to, `
+			select "import_id" from `+layout.Qualified("hibp_import")+`
+			where "finished_at" is not null and "source" = $1
+			order by "import_id" desc
+			limit 1`, config.source)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error finding the latest import", err)
+			os.Exit(1)
+		}
+	}
+	if config.from == 0 {
+		err := db.Get(&config.from, `
+			select "import_id" from `+layout.Qualified("hibp_import")+`
+			where "finished_at" is not null and "import_id" < $1 and "source" = $2
+			order by "import_id" desc
+			limit 1`, config.to, config.source)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error finding the import before", config.to, err)
+			os.Exit(1)
+		}
+	}
+	if config.from >= config.to {
+		fmt.Fprintln(os.Stderr, "--from must be an earlier import than --to")
+		os.Exit(1)
+	}
+
+	// an unlogged import breaks the history of its own source, a swap or
+	// truncate that replaced the data breaks the history of every source
+	var unlogged []int
+	err = db.Select(&unlogged, `
+		select "import_id" from `+layout.Qualified("hibp_import")+`
+		where "import_id" > $1 and "import_id" <= $2
+		and "strategy" <> all($3)
+		and ("source" = $4 or "strategy" = 'swap' or "truncated")
+		order by "import_id"`, config.from, config.to, pq.Array(loggedStrategies), config.source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error checking the imports between", config.from, "and", config.to, err)
+		os.Exit(1)
+	}
+	if len(unlogged) > 0 {
+		fmt.Fprintln(os.Stderr, "imports", unlogged, "don't log their changes, diff from", unlogged[len(unlogged)-1], "or later")
+		os.Exit(1)
+	}
+
+	// the first change of a hash in the range holds the count before it,
+	// the last change the count after it
+	rows, err := db.Queryx(`
+		with changes as (
+			select "import_id", "prefix", "hash", "previous_count", "count"
+			from `+layout.Qualified("hibp_change")+`
+			where "hash_type" = $3 and "source" = $4 and "import_id" > $1 and "import_id" <= $2
+		), first as (
+			select distinct on ("prefix", "hash") "prefix", "hash", "previous_count"
+			from changes
+			order by "prefix", "hash", "import_id"
+		), last as (
+			select distinct on ("prefix", "hash") "prefix", "hash", "count"
+			from changes
+			order by "prefix", "hash", "import_id" desc
+		)
+		select
+			case when f."previous_count" is null then '`+changeAdded+`' else '`+changeRose+`' end as "change",
+			f."prefix", f."hash", f."previous_count", l."count"
+		from first f
+		join last l on l."prefix" = f."prefix" and l."hash" = f."hash"
+		where f."previous_count" is null or l."count" > f."previous_count"
+		order by f."prefix", f."hash"`,
+		config.from, config.to, config.hashType, config.source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error querying changes", err)
+		os.Exit(1)
+	}
+	defer rows.Close()
+
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"change", "hash", "previous_count", "count"})
+
+	row := changedRow{}
+	for rows.Next() {
+		if err := rows.StructScan(&row); err != nil {
+			fmt.Fprintln(os.Stderr, "error while processing change record", err)
+			os.Exit(1)
+		}
+
+		previous := ""
+		if row.PreviousCount.Valid {
+			previous = strconv.FormatInt(row.PreviousCount.Int64, 10)
+		}
+		w.Write([]string{row.Change, row.Prefix + row.Hash, previous, strconv.Itoa(row.Count)})
+	}
+	if err := rows.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, "error querying changes", err)
+		os.Exit(1)
+	}
+
+	w.Flush()
+	return w.Error()
+}
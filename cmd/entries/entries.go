@@ -0,0 +1,71 @@
+// Package entries reads the passwords and hashes given to the list management commands.
+package entries
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/leesalminen/hibp/pwhash"
+)
+
+// TypePassword marks plaintext entries, which are stored as SHA-1 and NTLM hashes.
+const TypePassword = "password"
+
+// Entry is a single hash split into its k-anonymity prefix and suffix.
+type Entry struct {
+	HashType string
+	Prefix   string
+	Suffix   string
+}
+
+// Read collects the entries from args and from file, one per line, where
+// "-" reads from stdin, and hashes or validates them according to entryType.
+func Read(args []string, file, entryType string) ([]Entry, error) {
+	values := append([]string{}, args...)
+
+	if file != "" {
+		f := os.Stdin
+		if file != "-" {
+			var err error
+			f, err = os.Open(file)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+				values = append(values, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var entries []Entry
+	for _, value := range values {
+		switch entryType {
+		case TypePassword:
+			for _, hashType := range []string{pwhash.TypeSHA1, pwhash.TypeNTLM} {
+				prefix, suffix := pwhash.Split(pwhash.Hash(hashType, value))
+				entries = append(entries, Entry{HashType: hashType, Prefix: prefix, Suffix: suffix})
+			}
+		case pwhash.TypeSHA1, pwhash.TypeNTLM:
+			value = strings.TrimSpace(value)
+			if !pwhash.Valid(entryType, value) {
+				return nil, fmt.Errorf("%q is not a valid %s hash", value, entryType)
+			}
+			prefix, suffix := pwhash.Split(value)
+			entries = append(entries, Entry{HashType: entryType, Prefix: prefix, Suffix: suffix})
+		default:
+			return nil, fmt.Errorf("unsupported entry type %q, expected one of password, sha1, ntlm", entryType)
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,267 @@
+package exclusion
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"text/tabwriter"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/cmd/entries"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/model"
+	"github.com/leesalminen/hibp/pwhash"
+	"github.com/spf13/cobra"
+
+	// import postgres
+	_ "github.com/lib/pq"
+)
+
+// Command is the cobra command.
+var Command = &cobra.Command{
+	Use:   "exclusion",
+	Short: "Manage hashes that must never be reported as pwned",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+		os.Exit(1)
+	},
+}
+
+var addCommand = &cobra.Command{
+	Use:   "add [entry...]",
+	Short: "Exclude passwords or hashes from range responses and imports",
+	RunE:  runAdd,
+}
+
+var removeCommand = &cobra.Command{
+	Use:   "remove [entry...]",
+	Short: "Remove passwords or hashes from the exclusion list",
+	RunE:  runRemove,
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List excluded hashes",
+	RunE:  runList,
+}
+
+var auditCommand = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the audit trail of the exclusion list",
+	RunE:  runAudit,
+}
+
+// Audit trail actions.
+const (
+	actionAdd    = "add"
+	actionRemove = "remove"
+)
+
+type commandConfig struct {
+	dsn       string
+	schema    string
+	entryType string
+	file      string
+	reason    string
+	actor     string
+}
+
+var config = new(commandConfig)
+
+func initFlags() {
+	Command.PersistentFlags().StringVar(&config.dsn, "dsn", "", "Database connection string")
+	Command.PersistentFlags().StringVar(&config.schema, "schema", dataset.DefaultSchema, "Schema of the instance, as passed to migrate")
+
+	for _, c := range []*cobra.Command{addCommand, removeCommand} {
+		c.Flags().StringVar(&config.entryType, "type", pwhash.TypeSHA1, "Type of the entries: password, sha1 or ntlm")
+		c.Flags().StringVar(&config.file, "file", "", "Read entries from a file, one per line, - for stdin")
+		c.Flags().StringVar(&config.reason, "reason", "", "Why the entries are added or removed, recorded in the audit trail")
+		c.Flags().StringVar(&config.actor, "by", currentUser(), "Who adds or removes the entries, recorded in the audit trail")
+	}
+}
+
+func init() {
+	initFlags()
+	Command.AddCommand(addCommand)
+	Command.AddCommand(removeCommand)
+	Command.AddCommand(listCommand)
+	Command.AddCommand(auditCommand)
+}
+
+// currentUser returns the name of the operating system user, if known.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// connect connects to the database and loads the layout of --schema.
+func connect(ctx context.Context) (*sqlx.DB, dataset.Layout) {
+	db, err := sqlx.Connect("postgres", config.dsn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error establishing database connection", err)
+		os.Exit(1)
+	}
+	layout, err := dataset.LoadLayout(ctx, db, config.schema)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error loading the table layout", err)
+		os.Exit(1)
+	}
+	return db, layout
+}
+
+// readChange validates the flags shared by add and remove and reads the entries.
+func readChange(args []string) []entries.Entry {
+	if config.reason == "" {
+		fmt.Fprintln(os.Stderr, "the --reason flag is required")
+		os.Exit(1)
+	}
+	if config.actor == "" {
+		fmt.Fprintln(os.Stderr, "the --by flag is required")
+		os.Exit(1)
+	}
+
+	hashes, err := entries.Read(args, config.file, config.entryType)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error reading entries", err)
+		os.Exit(1)
+	}
+	return hashes
+}
+
+func runAdd(cmd *cobra.Command, args []string) error {
+	hashes := readChange(args)
+
+	db, layout := connect(cmd.Context())
+	defer db.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range hashes {
+		_, err := tx.Exec(`
+			insert into `+layout.Qualified("hibp_exclusion")+` ("hash_type", "prefix", "hash", "reason", "created_by")
+			values ($1, $2, $3, $4, $5)
+			on conflict ("hash_type", "prefix", "hash") do update
+			set "reason" = excluded."reason", "created_by" = excluded."created_by", "created_at" = now()`,
+			entry.HashType, entry.Prefix, entry.Suffix, config.reason, config.actor)
+		if err == nil {
+			err = audit(tx, layout, actionAdd, entry)
+		}
+		if err != nil {
+			tx.Rollback()
+			fmt.Fprintln(os.Stderr, "error adding exclusion", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Excluded %d hashes\n", len(hashes))
+	return nil
+}
+
+func runRemove(cmd *cobra.Command, args []string) error {
+	hashes := readChange(args)
+
+	db, layout := connect(cmd.Context())
+	defer db.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	var removed int64
+	for _, entry := range hashes {
+		res, err := tx.Exec(`
+			delete from `+layout.Qualified("hibp_exclusion")+`
+			where "hash_type" = $1 and "prefix" = $2 and "hash" = $3`,
+			entry.HashType, entry.Prefix, entry.Suffix)
+		if err != nil {
+			tx.Rollback()
+			fmt.Fprintln(os.Stderr, "error removing exclusion", err)
+			os.Exit(1)
+		}
+
+		n, _ := res.RowsAffected()
+		if n == 0 {
+			continue
+		}
+		removed += n
+
+		if err := audit(tx, layout, actionRemove, entry); err != nil {
+			tx.Rollback()
+			fmt.Fprintln(os.Stderr, "error removing exclusion", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed %d exclusions\n", removed)
+	return nil
+}
+
+// audit records a change to the exclusion list.
+func audit(tx *sqlx.Tx, layout dataset.Layout, action string, entry entries.Entry) error {
+	_, err := tx.Exec(`
+		insert into `+layout.Qualified("hibp_exclusion_audit")+` ("action", "hash_type", "prefix", "hash", "reason", "actor")
+		values ($1, $2, $3, $4, $5, $6)`,
+		action, entry.HashType, entry.Prefix, entry.Suffix, config.reason, config.actor)
+	return err
+}
+
+func runList(cmd *cobra.Command, _ []string) error {
+	db, layout := connect(cmd.Context())
+	defer db.Close()
+
+	var exclusions []model.Exclusion
+	err := db.Select(&exclusions, `
+		select "hash_type", "prefix", "hash", "reason", "created_by", "created_at"
+		from `+layout.Qualified("hibp_exclusion")+`
+		order by "hash_type", "prefix", "hash"`)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error listing exclusions", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tHASH\tBY\tCREATED\tREASON")
+	for _, e := range exclusions {
+		fmt.Fprintf(w, "%s\t%s%s\t%s\t%s\t%s\n",
+			e.HashType, e.Prefix, e.Hash, e.CreatedBy, e.CreatedAt.Format("2006-01-02 15:04:05"), e.Reason)
+	}
+	return w.Flush()
+}
+
+func runAudit(cmd *cobra.Command, _ []string) error {
+	db, layout := connect(cmd.Context())
+	defer db.Close()
+
+	var trail []model.ExclusionAudit
+	err := db.Select(&trail, `
+		select "audit_id", "action", "hash_type", "prefix", "hash", "reason", "actor", "created_at"
+		from `+layout.Qualified("hibp_exclusion_audit")+`
+		order by "audit_id"`)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error reading the audit trail", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tACTION\tTYPE\tHASH\tBY\tREASON")
+	for _, a := range trail {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s%s\t%s\t%s\n",
+			a.CreatedAt.Format("2006-01-02 15:04:05"), a.Action, a.HashType, a.Prefix, a.Hash, a.Actor, a.Reason)
+	}
+	return w.Flush()
+}
@@ -1,10 +1,12 @@
 package migrate
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"strings"
 
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/pwhash"
 	"github.com/spf13/cobra"
 
 	"github.com/jmoiron/sqlx"
@@ -21,52 +23,188 @@ var Command = &cobra.Command{
 }
 
 type commandConfig struct {
-	dsn string
+	dsn             string
+	schema          string
+	table           string
+	partitions      int
+	partitionMethod string
 }
 
 var config = new(commandConfig)
 
 func initFlags() {
 	Command.Flags().StringVar(&config.dsn, "dsn", "", "Database connection string")
+	Command.Flags().StringVar(&config.schema, "schema", dataset.DefaultSchema, "Schema of the hash tables and every other table, migrate one schema per instance to run several in one database")
+	Command.Flags().StringVar(&config.table, "table", "", "Name of the SHA-1 hash table, the NTLM table gets the suffix _ntlm (default: the recorded layout or hibp)")
+	Command.Flags().IntVar(&config.partitions, "partitions", 0, "Number of partitions of each hash table: 16, 256 or 4096 (default: the recorded layout or 256)")
+	Command.Flags().StringVar(&config.partitionMethod, "partition-method", "", "Partition by the first prefix characters (list) or by a hash of the prefix (hash) (default: the recorded layout or list)")
 }
 
 func init() {
 	initFlags()
 }
 
-func generatePartitionSchema() string {
-	baseSchema := `
-CREATE TABLE public.hibp (
-	row_id serial NOT NULL,
-	partition_prefix varchar(2) NOT NULL,
+// generatePartitionSchema creates the hash tables and every table of the
+// instance in the layout schema.
+func generatePartitionSchema(layout dataset.Layout) string {
+	return "CREATE SCHEMA IF NOT EXISTS " + layout.Schema + ";\n" +
+		layout.TableSchema(layout.Schema, layout.TableName(pwhash.TypeSHA1)) +
+		layout.TableSchema(layout.Schema, layout.TableName(pwhash.TypeNTLM)) +
+		dataset.LayoutSchema(layout.Schema) +
+		fmt.Sprintf(lazyPrefixSchema+
+			rangeVersionSchema+
+			customSchema+
+			exclusionSchema+
+			importSchema+
+			changeSchema+
+			watchlistSchema+
+			apiKeySchema, layout.Schema)
+}
+
+// customSchema holds the organization specific banned password lists.
+// It is not touched by data-import, so the lists survive a truncate.
+const customSchema = `
+CREATE TABLE IF NOT EXISTS %[1]s.hibp_custom (
+	hash_type varchar(4) NOT NULL,
+	prefix varchar(5) NOT NULL,
+	hash varchar(35) NOT NULL,
+	source varchar(100) NOT NULL,
+	count integer NOT NULL DEFAULT 1,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	CONSTRAINT hibp_custom_pkey PRIMARY KEY (hash_type, prefix, hash, source)
+);
+CREATE INDEX IF NOT EXISTS hibp_custom_source_idx ON %[1]s.hibp_custom (source);
+`
+
+// exclusionSchema holds the hashes that must never be reported as pwned,
+// together with an audit trail of every change to the list.
+const exclusionSchema = `
+CREATE TABLE IF NOT EXISTS %[1]s.hibp_exclusion (
+	hash_type varchar(4) NOT NULL,
+	prefix varchar(5) NOT NULL,
+	hash varchar(35) NOT NULL,
+	reason text NOT NULL,
+	created_by varchar(100) NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	CONSTRAINT hibp_exclusion_pkey PRIMARY KEY (hash_type, prefix, hash)
+);
+CREATE TABLE IF NOT EXISTS %[1]s.hibp_exclusion_audit (
+	audit_id serial NOT NULL,
+	action varchar(10) NOT NULL,
+	hash_type varchar(4) NOT NULL,
 	prefix varchar(5) NOT NULL,
-	hash varchar(40) NOT NULL,
+	hash varchar(35) NOT NULL,
+	reason text NOT NULL,
+	actor varchar(100) NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	CONSTRAINT hibp_exclusion_audit_pkey PRIMARY KEY (audit_id)
+);
+`
+
+// importSchema holds the dataset metadata, one row per data-import run.
+const importSchema = `
+CREATE TABLE IF NOT EXISTS %[1]s.hibp_import (
+	import_id serial NOT NULL,
+	source text NOT NULL,
+	strategy varchar(10) NOT NULL DEFAULT 'truncate',
+	min_count integer NOT NULL DEFAULT 0,
+	truncated boolean NOT NULL,
+	started_at timestamptz NOT NULL DEFAULT now(),
+	finished_at timestamptz,
+	CONSTRAINT hibp_import_pkey PRIMARY KEY (import_id)
+);
+ALTER TABLE %[1]s.hibp_import ALTER COLUMN strategy TYPE varchar(20);
+ALTER TABLE %[1]s.hibp_import
+	ADD COLUMN IF NOT EXISTS checkpoint varchar(5),
+	ADD COLUMN IF NOT EXISTS fast_load boolean NOT NULL DEFAULT false;
+`
+
+// changeSchema logs the hashes every merge or incremental import added or
+// changed in the rows of its source, so that hibp diff can compare any two
+// imports of a source.
+const changeSchema = `
+CREATE TABLE IF NOT EXISTS %[1]s.hibp_change (
+	import_id integer NOT NULL,
+	hash_type varchar(4) NOT NULL,
+	prefix varchar(5) NOT NULL,
+	hash varchar(35) NOT NULL,
+	source text NOT NULL DEFAULT 'api',
+	previous_count integer,
 	count integer NOT NULL,
-	CONSTRAINT hibp_pkey PRIMARY KEY (row_id, partition_prefix, prefix)
-) PARTITION BY LIST (partition_prefix);
+	CONSTRAINT hibp_change_pkey PRIMARY KEY (import_id, hash_type, prefix, hash)
+);
+`
+
+// watchlistSchema holds the hashes re-checked after every import,
+// registered under opaque IDs.
+const watchlistSchema = `
+CREATE TABLE IF NOT EXISTS %[1]s.hibp_watchlist (
+	watch_id varchar(200) NOT NULL,
+	hash_type varchar(4) NOT NULL,
+	prefix varchar(5) NOT NULL,
+	hash varchar(35) NOT NULL,
+	pwned_count integer NOT NULL DEFAULT 0,
+	pwned_import integer,
+	checked_at timestamptz,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	CONSTRAINT hibp_watchlist_pkey PRIMARY KEY (watch_id)
+);
+`
+
+// apiKeySchema stores the hashed API keys of the range endpoint, their limits and usage.
+const apiKeySchema = `
+CREATE TABLE IF NOT EXISTS %[1]s.hibp_api_key (
+	key_id serial NOT NULL,
+	name varchar(200) NOT NULL,
+	key_hash varchar(64) NOT NULL,
+	enabled boolean NOT NULL DEFAULT true,
+	request_count bigint NOT NULL DEFAULT 0,
+	last_used_at timestamptz,
+	revoked_at timestamptz,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	CONSTRAINT hibp_api_key_pkey PRIMARY KEY (key_id),
+	CONSTRAINT hibp_api_key_hash_key UNIQUE (key_hash)
+);
+-- a revoked name can be issued again
+CREATE UNIQUE INDEX IF NOT EXISTS hibp_api_key_name_idx ON %[1]s.hibp_api_key (name) WHERE revoked_at IS NULL;
+-- per key limits, null uses the serve defaults
+ALTER TABLE %[1]s.hibp_api_key
+	ADD COLUMN IF NOT EXISTS rate_limit double precision,
+	ADD COLUMN IF NOT EXISTS rate_burst integer,
+	ADD COLUMN IF NOT EXISTS daily_quota bigint;
+CREATE TABLE IF NOT EXISTS %[1]s.hibp_api_key_usage (
+	key_id integer NOT NULL,
+	day date NOT NULL,
+	requests bigint NOT NULL DEFAULT 0,
+	CONSTRAINT hibp_api_key_usage_pkey PRIMARY KEY (key_id, day)
+);
+`
+
+// lazyPrefixSchema records the prefixes serve filled from the upstream API
+// because they were missing from the imported data.
+const lazyPrefixSchema = `
+CREATE TABLE IF NOT EXISTS %[1]s.hibp_lazy_prefix (
+	hash_type varchar(4) NOT NULL DEFAULT 'sha1',
+	prefix varchar(5) NOT NULL,
+	filled_at timestamptz NOT NULL DEFAULT now(),
+	CONSTRAINT hibp_lazy_prefix_pkey PRIMARY KEY (hash_type, prefix)
+);
+`
+
+// rangeVersionSchema holds the upstream validators of every imported range,
+// so that an incremental import only replaces the ranges that changed.
+const rangeVersionSchema = `
+CREATE TABLE IF NOT EXISTS %[1]s.hibp_range_version (
+	hash_type varchar(4) NOT NULL DEFAULT 'sha1',
+	prefix varchar(5) NOT NULL,
+	etag text NOT NULL DEFAULT '',
+	last_modified text NOT NULL DEFAULT '',
+	min_count integer NOT NULL DEFAULT 0,
+	import_id integer NOT NULL,
+	changed_at timestamptz NOT NULL DEFAULT now(),
+	CONSTRAINT hibp_range_version_pkey PRIMARY KEY (hash_type, prefix)
+);
 `
-	
-	var partitions, indexes strings.Builder
-	
-	// Generate partitions and indexes for all hex prefixes (00-FF)
-	for i := 0; i <= 255; i++ {
-		prefix := fmt.Sprintf("%02X", i)
-		
-		// Create partition
-		partitions.WriteString(fmt.Sprintf(
-			"CREATE TABLE hibp_prefix_%s PARTITION OF hibp FOR VALUES IN ('%s');\n",
-			prefix, prefix,
-		))
-		
-		// Create index for the partition
-		indexes.WriteString(fmt.Sprintf(
-			"CREATE INDEX hibp_prefix_idx_%s ON hibp_prefix_%s (prefix);\n",
-			prefix, prefix,
-		))
-	}
-	
-	return baseSchema + partitions.String() + indexes.String()
-}
 
 func run(cmd *cobra.Command, _ []string) error {
 	db, err := sqlx.Connect("postgres", config.dsn)
@@ -76,13 +214,61 @@ func run(cmd *cobra.Command, _ []string) error {
 	}
 	defer db.Close()
 
+	layout, err := requestedLayout(cmd.Context(), db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error choosing the table layout", err)
+		os.Exit(1)
+	}
+
 	// Generate and execute the schema
-	schema := generatePartitionSchema()
+	schema := generatePartitionSchema(layout)
 	_, schemaErr := db.Exec(schema)
 	if schemaErr != nil {
 		fmt.Fprintln(os.Stderr, "error creating schema", schemaErr)
 		os.Exit(1)
 	}
 
+	if err := dataset.SaveLayout(cmd.Context(), db, layout); err != nil {
+		fmt.Fprintln(os.Stderr, "error recording the table layout", err)
+		os.Exit(1)
+	}
+
 	return nil
 }
+
+// requestedLayout returns the layout recorded in --schema with the flags
+// applied. The layout of existing hash tables can't be changed, they must be
+// dropped first.
+func requestedLayout(ctx context.Context, db *sqlx.DB) (dataset.Layout, error) {
+	recorded, err := dataset.LoadLayout(ctx, db, config.schema)
+	if err != nil {
+		return recorded, err
+	}
+
+	layout := recorded
+	if config.table != "" {
+		layout.Table = config.table
+	}
+	if config.partitions != 0 {
+		layout.Partitions = config.partitions
+	}
+	if config.partitionMethod != "" {
+		layout.Method = config.partitionMethod
+	}
+	if err := layout.Validate(); err != nil {
+		return layout, err
+	}
+	if layout == recorded {
+		return layout, nil
+	}
+
+	var exists bool
+	err = db.GetContext(ctx, &exists, `select to_regclass($1) is not null`, recorded.QualifiedTable(pwhash.TypeSHA1))
+	if err != nil {
+		return layout, err
+	}
+	if exists {
+		return layout, fmt.Errorf("the hash tables exist as %s, drop them to change the layout", recorded)
+	}
+	return layout, nil
+}
@@ -0,0 +1,59 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/leesalminen/hibp/mtls"
+	"github.com/leesalminen/hibp/ratelimit"
+)
+
+type accessLogKey struct{}
+
+// accessEntry collects what the handlers know about a request for its access log line.
+type accessEntry struct {
+	client string
+}
+
+// statusRecorder remembers the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// accessLog writes a line per request to stdout with the client, the
+// request, the response status and the duration.
+func accessLog(next http.Handler, trustedProxies []*net.IPNet) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		entry := &accessEntry{client: principalName(mtls.Principal(r))}
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), accessLogKey{}, entry)))
+
+		fmt.Fprintf(os.Stdout, "%s %s %s \"%s %s\" %d %s\n",
+			started.UTC().Format(time.RFC3339),
+			ratelimit.ClientIP(r, trustedProxies),
+			entry.client,
+			r.Method,
+			r.URL.RequestURI(),
+			recorder.status,
+			time.Since(started).Round(time.Microsecond))
+	})
+}
+
+// setAccessClient records the client of r for the access log, if enabled.
+func setAccessClient(r *http.Request, client string) {
+	if entry, ok := r.Context().Value(accessLogKey{}).(*accessEntry); ok {
+		entry.client = client
+	}
+}
@@ -0,0 +1,430 @@
+package serve
+
+import (
+	"context"
+	"crypto/subtle"
+	stderrors "errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	flags "github.com/jessevdk/go-flags"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/leesalminen/hibp/api/admin/models"
+	adminserver "github.com/leesalminen/hibp/api/admin/server"
+	adminapi "github.com/leesalminen/hibp/api/admin/server/restapi"
+	cacheapi "github.com/leesalminen/hibp/api/admin/server/restapi/cache"
+	importsapi "github.com/leesalminen/hibp/api/admin/server/restapi/imports"
+	keysapi "github.com/leesalminen/hibp/api/admin/server/restapi/keys"
+	reloadapi "github.com/leesalminen/hibp/api/admin/server/restapi/reload"
+	statusapi "github.com/leesalminen/hibp/api/admin/server/restapi/status"
+	"github.com/leesalminen/hibp/apikey"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/model"
+	"github.com/leesalminen/hibp/mtls"
+	"github.com/leesalminen/hibp/pwhash"
+)
+
+const (
+	// adminUnixPrefix marks an --admin-listen unix socket path.
+	adminUnixPrefix = "unix:"
+	// methodAdminToken is the principal method of the admin token.
+	methodAdminToken = "admin-token"
+	// adminHeader is the request header carrying the admin token.
+	adminHeader = "hibp-admin-token"
+	// recentImports is the number of imports listed on the status endpoint.
+	recentImports = 10
+)
+
+// statusTables returns the tables whose size the status endpoint reports.
+func statusTables(layout dataset.Layout) []string {
+	return []string{layout.QualifiedTable(pwhash.TypeSHA1), layout.QualifiedTable(pwhash.TypeNTLM), layout.Qualified("hibp_custom"), layout.Qualified("hibp_exclusion")}
+}
+
+// admin serves the admin API.
+type admin struct {
+	db      *sqlx.DB
+	layout  dataset.Layout
+	keys    *apikey.Authenticator
+	imports *importRunner
+	refresh *refresher
+	// certs is nil unless serve uses --tls-certificate
+	certs       *certReloader
+	clientCerts *mtls.Verifier
+	token       string
+}
+
+// readAdminToken reads the admin token from file, ignoring surrounding whitespace.
+func readAdminToken(file string) (string, error) {
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(buf))
+	if token == "" {
+		return "", fmt.Errorf("%s is empty", file)
+	}
+	return token, nil
+}
+
+// newAdminServer creates the admin server listening on addr, host:port or
+// unix:PATH. It listens before returning, so that address errors are
+// reported on startup.
+func newAdminServer(a *admin, addr string) (*adminserver.Server, error) {
+	doc, err := loads.Embedded(adminserver.SwaggerJSON, adminserver.FlatSwaggerJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	api := adminapi.NewHIBPAdminAPI(doc)
+	a.configure(api)
+
+	s := adminserver.NewServer(api)
+	socket := strings.TrimPrefix(addr, adminUnixPrefix)
+	if socket == addr {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		s.Port, err = strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q", port)
+		}
+		s.EnabledListeners = []string{"http"}
+		s.Host = host
+		if err := s.Listen(); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	// a previous instance may have left its socket behind:
+	if info, err := os.Lstat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(socket)
+	}
+
+	// the socket is bound in a directory only the user of serve can enter
+	// and moved into place once it is restricted to that user, so no other
+	// user can ever connect to it:
+	dir, err := ioutil.TempDir(filepath.Dir(socket), ".hibp-admin-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	bound := filepath.Join(dir, "admin.sock")
+
+	s.EnabledListeners = []string{"unix"}
+	s.SocketPath = flags.Filename(bound)
+	if err := s.Listen(); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(bound, 0600); err != nil {
+		s.Shutdown()
+		return nil, err
+	}
+	if err := os.Rename(bound, socket); err != nil {
+		s.Shutdown()
+		return nil, err
+	}
+	s.SocketPath = flags.Filename(socket)
+	return s, nil
+}
+
+// configure sets the authentication and the handlers of api.
+func (a *admin) configure(api *adminapi.HIBPAdminAPI) {
+	api.AdminTokenAuth = func(token string) (*model.Principal, error) {
+		if a.token == "" {
+			return nil, errors.New(http.StatusUnauthorized, "admin tokens are not accepted")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			return nil, errors.New(http.StatusUnauthorized, "invalid admin token")
+		}
+		return &model.Principal{Name: "admin", Method: methodAdminToken}, nil
+	}
+	if a.token != "" {
+		api.APIAuthorizer = runtime.AuthorizerFunc(func(_ *http.Request, principal interface{}) error {
+			if principal == nil {
+				return errors.New(http.StatusUnauthorized, "missing %s header", adminHeader)
+			}
+			return nil
+		})
+	}
+
+	api.StatusGetStatusHandler = statusapi.GetStatusHandlerFunc(func(params statusapi.GetStatusParams, _ *model.Principal) middleware.Responder {
+		status, err := a.status(params.HTTPRequest.Context())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error reading dataset status", err)
+			return statusapi.NewGetStatusInternalServerError().WithPayload(adminError(http.StatusInternalServerError, "error reading dataset status"))
+		}
+		return statusapi.NewGetStatusOK().WithPayload(status)
+	})
+
+	api.CacheGetCacheHandler = cacheapi.GetCacheHandlerFunc(func(cacheapi.GetCacheParams, *model.Principal) middleware.Responder {
+		return cacheapi.NewGetCacheOK().WithPayload(&models.CacheStatus{APIKeys: int64(a.keys.Cached())})
+	})
+	api.CacheFlushCacheHandler = cacheapi.FlushCacheHandlerFunc(func(cacheapi.FlushCacheParams, *model.Principal) middleware.Responder {
+		a.keys.Purge()
+		return cacheapi.NewFlushCacheNoContent()
+	})
+
+	api.ReloadReloadHandler = reloadapi.ReloadHandlerFunc(func(reloadapi.ReloadParams, *model.Principal) middleware.Responder {
+		if err := a.reload(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return reloadapi.NewReloadInternalServerError().WithPayload(adminError(http.StatusInternalServerError, err.Error()))
+		}
+		return reloadapi.NewReloadNoContent()
+	})
+
+	api.ImportsGetImportHandler = importsapi.GetImportHandlerFunc(func(importsapi.GetImportParams, *model.Principal) middleware.Responder {
+		return importsapi.NewGetImportOK().WithPayload(importRunModel(a.imports.status()))
+	})
+	api.ImportsStartImportHandler = importsapi.StartImportHandlerFunc(func(params importsapi.StartImportParams, _ *model.Principal) middleware.Responder {
+		source, strategy := "api", "swap"
+		if params.Body.Source != nil {
+			source = *params.Body.Source
+		}
+		if params.Body.Strategy != nil {
+			strategy = *params.Body.Strategy
+		}
+		if source != "api" && !strings.HasPrefix(source, "wordlist:") {
+			return importsapi.NewStartImportBadRequest().WithPayload(adminError(http.StatusBadRequest, "source must be api or wordlist:PATH"))
+		}
+		if strategy == "incremental" && source != "api" {
+			return importsapi.NewStartImportBadRequest().WithPayload(adminError(http.StatusBadRequest, "the incremental strategy requires the api source"))
+		}
+		if source != "api" && strategy != "merge" {
+			return importsapi.NewStartImportBadRequest().WithPayload(adminError(http.StatusBadRequest, "a wordlist source with the "+strategy+" strategy replaces the HIBP data, use the merge strategy to add it next to the HIBP data"))
+		}
+
+		run, err := a.imports.start(triggerAdmin, []string{
+			"--source=" + source,
+			"--strategy=" + strategy,
+			"--min-count=" + strconv.FormatInt(params.Body.MinCount, 10),
+		})
+		if err == errImportRunning {
+			return importsapi.NewStartImportConflict().WithPayload(adminError(http.StatusConflict, err.Error()))
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error starting data-import", err)
+			return importsapi.NewStartImportInternalServerError().WithPayload(adminError(http.StatusInternalServerError, "error starting data-import"))
+		}
+		return importsapi.NewStartImportAccepted().WithPayload(importRunModel(run))
+	})
+
+	api.KeysListKeysHandler = keysapi.ListKeysHandlerFunc(func(params keysapi.ListKeysParams, _ *model.Principal) middleware.Responder {
+		keys, err := apikey.List(params.HTTPRequest.Context(), a.db, a.layout.Schema)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error listing API keys", err)
+			return keysapi.NewListKeysInternalServerError().WithPayload(adminError(http.StatusInternalServerError, "error listing API keys"))
+		}
+		payload := make([]*models.APIKey, 0, len(keys))
+		for _, key := range keys {
+			payload = append(payload, apiKeyModel(key))
+		}
+		return keysapi.NewListKeysOK().WithPayload(payload)
+	})
+	api.KeysCreateKeyHandler = keysapi.CreateKeyHandlerFunc(func(params keysapi.CreateKeyParams, _ *model.Principal) middleware.Responder {
+		name := *params.Body.Name
+		key, err := apikey.Create(params.HTTPRequest.Context(), a.db, a.layout.Schema, name, limits(params.Body.Limits))
+		if err == apikey.ErrNameTaken {
+			return keysapi.NewCreateKeyConflict().WithPayload(adminError(http.StatusConflict, err.Error()))
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error creating API key", err)
+			return keysapi.NewCreateKeyInternalServerError().WithPayload(adminError(http.StatusInternalServerError, "error creating API key"))
+		}
+		return keysapi.NewCreateKeyCreated().WithPayload(&models.CreatedKey{Name: name, Key: key})
+	})
+	api.KeysSetKeyLimitsHandler = keysapi.SetKeyLimitsHandlerFunc(func(params keysapi.SetKeyLimitsParams, _ *model.Principal) middleware.Responder {
+		err := apikey.SetLimits(params.HTTPRequest.Context(), a.db, a.layout.Schema, params.Name, limits(params.Body))
+		switch {
+		case stderrors.Is(err, apikey.ErrNotFound):
+			return keysapi.NewSetKeyLimitsNotFound().WithPayload(adminError(http.StatusNotFound, err.Error()))
+		case err != nil:
+			fmt.Fprintln(os.Stderr, "error updating API key", params.Name, err)
+			return keysapi.NewSetKeyLimitsInternalServerError().WithPayload(adminError(http.StatusInternalServerError, "error updating API key"))
+		}
+		return keysapi.NewSetKeyLimitsNoContent()
+	})
+	api.KeysEnableKeyHandler = keysapi.EnableKeyHandlerFunc(func(params keysapi.EnableKeyParams, _ *model.Principal) middleware.Responder {
+		err := apikey.SetEnabled(params.HTTPRequest.Context(), a.db, a.layout.Schema, params.Name, true)
+		switch {
+		case stderrors.Is(err, apikey.ErrNotFound):
+			return keysapi.NewEnableKeyNotFound().WithPayload(adminError(http.StatusNotFound, err.Error()))
+		case err != nil:
+			fmt.Fprintln(os.Stderr, "error enabling API key", params.Name, err)
+			return keysapi.NewEnableKeyInternalServerError().WithPayload(adminError(http.StatusInternalServerError, "error enabling API key"))
+		}
+		// accept the key right away, it may be cached as invalid
+		a.keys.Purge()
+		return keysapi.NewEnableKeyNoContent()
+	})
+	api.KeysDisableKeyHandler = keysapi.DisableKeyHandlerFunc(func(params keysapi.DisableKeyParams, _ *model.Principal) middleware.Responder {
+		err := apikey.SetEnabled(params.HTTPRequest.Context(), a.db, a.layout.Schema, params.Name, false)
+		switch {
+		case stderrors.Is(err, apikey.ErrNotFound):
+			return keysapi.NewDisableKeyNotFound().WithPayload(adminError(http.StatusNotFound, err.Error()))
+		case err != nil:
+			fmt.Fprintln(os.Stderr, "error disabling API key", params.Name, err)
+			return keysapi.NewDisableKeyInternalServerError().WithPayload(adminError(http.StatusInternalServerError, "error disabling API key"))
+		}
+		// reject the key right away instead of after the cache TTL
+		a.keys.Purge()
+		return keysapi.NewDisableKeyNoContent()
+	})
+	api.KeysRevokeKeyHandler = keysapi.RevokeKeyHandlerFunc(func(params keysapi.RevokeKeyParams, _ *model.Principal) middleware.Responder {
+		err := apikey.Revoke(params.HTTPRequest.Context(), a.db, a.layout.Schema, params.Name)
+		switch {
+		case stderrors.Is(err, apikey.ErrNotFound):
+			return keysapi.NewRevokeKeyNotFound().WithPayload(adminError(http.StatusNotFound, err.Error()))
+		case err != nil:
+			fmt.Fprintln(os.Stderr, "error revoking API key", params.Name, err)
+			return keysapi.NewRevokeKeyInternalServerError().WithPayload(adminError(http.StatusInternalServerError, "error revoking API key"))
+		}
+		a.keys.Purge()
+		return keysapi.NewRevokeKeyNoContent()
+	})
+}
+
+// reload reloads the TLS certificate and the changed CRL files and drops
+// the cached API keys. Certificates that fail to load are kept.
+func (a *admin) reload() error {
+	a.keys.Purge()
+	if a.certs != nil {
+		if err := a.certs.reload(); err != nil {
+			return fmt.Errorf("error reloading TLS certificate, keeping the previous one: %w", err)
+		}
+	}
+	if err := a.clientCerts.Reload(); err != nil {
+		return fmt.Errorf("error reloading CRLs, keeping the previous ones: %w", err)
+	}
+	return nil
+}
+
+// status reads the recent imports and the table sizes.
+func (a *admin) status(ctx context.Context) (*models.Status, error) {
+	status := &models.Status{
+		Imports:   []*models.Import{},
+		Tables:    []*models.Table{},
+		ImportRun: importRunModel(a.imports.status()),
+	}
+
+	var imports []model.Import
+	err := a.db.SelectContext(ctx, &imports, `
+		select "import_id", "source", "strategy", "min_count", "truncated", "started_at", "finished_at"
+		from `+a.layout.Qualified("hibp_import")+`
+		order by "import_id" desc
+		limit $1`, recentImports)
+	if err != nil {
+		return nil, err
+	}
+	for _, imp := range imports {
+		status.Imports = append(status.Imports, &models.Import{
+			ImportID:   int64(imp.ImportID),
+			Source:     imp.Source,
+			Strategy:   imp.Strategy,
+			MinCount:   int64(imp.MinCount),
+			Truncated:  imp.Truncated,
+			StartedAt:  strfmt.DateTime(imp.StartedAt),
+			FinishedAt: dateTime(imp.FinishedAt),
+		})
+	}
+
+	for _, table := range statusTables(a.layout) {
+		// partitioned tables have no statistics of their own, sum up their partitions:
+		var rows int64
+		err := a.db.GetContext(ctx, &rows, `
+			select coalesce(sum(greatest(c."reltuples", 0)), 0)::bigint
+			from pg_class c
+			where c."oid" = $1::regclass
+			or c."oid" in (select "inhrelid" from pg_inherits where "inhparent" = $1::regclass)`, table)
+		if err != nil {
+			return nil, err
+		}
+		status.Tables = append(status.Tables, &models.Table{Name: table, EstimatedRows: rows})
+	}
+
+	if err := a.db.GetContext(ctx, &status.LazyPrefixes, `select count(*) from `+a.layout.Qualified("hibp_lazy_prefix")); err != nil {
+		return nil, err
+	}
+
+	status.ImportLocked, err = dataset.ImportLocked(ctx, a.db, a.layout.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.refresh != nil {
+		status.Refresh = &models.Refresh{
+			Schedule:  a.refresh.spec,
+			NextRunAt: strfmt.DateTime(a.refresh.nextRun()),
+		}
+	}
+
+	return status, nil
+}
+
+func adminError(code int32, message string) *models.Error {
+	return &models.Error{Code: code, Message: message}
+}
+
+func limits(l *models.Limits) apikey.Limits {
+	if l == nil {
+		return apikey.Limits{}
+	}
+	return apikey.Limits{Rate: l.Rate, Burst: int(l.Burst), DailyQuota: l.DailyQuota}
+}
+
+func apiKeyModel(key model.APIKey) *models.APIKey {
+	m := &models.APIKey{
+		Name:         key.Name,
+		Enabled:      key.Enabled,
+		RateLimit:    key.RateLimit,
+		DailyQuota:   key.DailyQuota,
+		RequestCount: key.RequestCount,
+		LastUsedAt:   dateTime(key.LastUsedAt),
+		RevokedAt:    dateTime(key.RevokedAt),
+		CreatedAt:    strfmt.DateTime(key.CreatedAt),
+	}
+	if key.RateBurst != nil {
+		burst := int64(*key.RateBurst)
+		m.RateBurst = &burst
+	}
+	return m
+}
+
+func importRunModel(run importRun) *models.ImportRun {
+	m := &models.ImportRun{
+		Running:    run.Running,
+		Trigger:    run.Trigger,
+		Args:       run.Args,
+		StartedAt:  dateTime(run.StartedAt),
+		FinishedAt: dateTime(run.FinishedAt),
+	}
+	if m.Args == nil {
+		m.Args = []string{}
+	}
+	if run.Err != nil {
+		m.Error = run.Err.Error()
+	}
+	return m
+}
+
+func dateTime(t *time.Time) *strfmt.DateTime {
+	if t == nil {
+		return nil
+	}
+	dt := strfmt.DateTime(*t)
+	return &dt
+}
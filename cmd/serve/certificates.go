@@ -0,0 +1,152 @@
+package serve
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// certReloader serves the certificate of --tls-certificate and --tls-key
+// and reloads it when the files change or on SIGHUP. Connections keep
+// the certificate they were established with.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload loads the certificate, keeping the previous one if that fails.
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.mu.Unlock()
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// watch reloads the certificate on SIGHUP and when the files change until ctx is done.
+// The directories are watched so that files replaced by a rename or a symlink
+// swap, as done by Kubernetes secrets, are noticed.
+func (r *certReloader) watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	dirs := map[string]bool{filepath.Dir(r.certFile): true, filepath.Dir(r.keyFile): true}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer watcher.Close()
+		defer signal.Stop(hup)
+
+		// a certificate and its key are usually written one after the other,
+		// reload once both are in place:
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				r.reloadAndLog("SIGHUP")
+			case event := <-watcher.Events:
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					debounce = time.After(time.Second)
+				}
+			case <-debounce:
+				r.reloadAndLog("file change")
+			case err := <-watcher.Errors:
+				fmt.Fprintln(os.Stderr, "error watching TLS certificate", err)
+			}
+		}
+	}()
+	return nil
+}
+
+func (r *certReloader) reloadAndLog(reason string) {
+	if err := r.reload(); err != nil {
+		fmt.Fprintln(os.Stderr, "error reloading TLS certificate after", reason+", keeping the previous one:", err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, "reloaded TLS certificate after", reason)
+}
+
+// newACMEManager creates the autocert manager of --acme-domain.
+func newACMEManager(domains []string, directoryURL, email, cacheDir, caFile string) (*autocert.Manager, error) {
+	httpClient := http.DefaultClient
+	if caFile != "" {
+		// e.g. the root of a local test server like Pebble
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", caFile)
+		}
+		httpClient = &http.Client{Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		}}
+	}
+
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      email,
+		Client: &acme.Client{
+			DirectoryURL: directoryURL,
+			HTTPClient:   httpClient,
+		},
+	}, nil
+}
+
+// useCertificates makes tlsConfig serve certificates from getCertificate
+// instead of the ones loaded at startup.
+func useCertificates(tlsConfig *tls.Config, getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) {
+	tlsConfig.Certificates = nil
+	tlsConfig.NameToCertificate = nil
+	tlsConfig.GetCertificate = getCertificate
+}
@@ -1,18 +1,37 @@
 package serve
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"net/http"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/leesalminen/hibp/api/server"
 	"github.com/leesalminen/hibp/api/server/restapi"
 	"github.com/leesalminen/hibp/api/server/restapi/range_restapi"
+	"github.com/leesalminen/hibp/apikey"
+	"github.com/leesalminen/hibp/checker"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/jwtauth"
 	"github.com/leesalminen/hibp/model"
+	"github.com/leesalminen/hibp/mtls"
+	"github.com/leesalminen/hibp/pwhash"
+	"github.com/leesalminen/hibp/ratelimit"
+	"github.com/leesalminen/hibp/store"
+	"github.com/leesalminen/hibp/upstream"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
 
+	"github.com/go-openapi/errors"
 	"github.com/go-openapi/loads"
+	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/runtime/middleware"
+	flags "github.com/jessevdk/go-flags"
 
 	"github.com/jmoiron/sqlx"
 
@@ -28,19 +47,120 @@ var Command = &cobra.Command{
 }
 
 type commandConfig struct {
-	dsn      string
-	bindHost string
-	bindPort int
-	schemes  []string
+	dsn              string
+	schema           string
+	bindHost         string
+	bindPort         int
+	schemes          []string
+	notFoundBehavior string
+	skipDatasetCheck bool
+	upstreamFallback bool
+	upstreamURL      string
+	upstreamTimeout  time.Duration
+	upstreamRequest  time.Duration
+	customCount      int
+	minCount         int
+	requireAuth      bool
+	apiKeyCacheTTL   time.Duration
+	rateLimit        float64
+	rateBurst        int
+	trustedProxies   []string
+	dailyQuotas      bool
+	jwt              jwtauth.Options
+	tlsHost          string
+	tlsPort          int
+	tlsCertificate   string
+	tlsKey           string
+	tlsCA            string
+	tlsClientAuth    string
+	mtls             mtls.Options
+	accessLog        bool
+	acmeDomains      []string
+	acmeDirectoryURL string
+	acmeEmail        string
+	acmeCacheDir     string
+	acmeCAFile       string
+	adminListen      string
+	adminTokenFile   string
+	refreshSchedule  string
+	refreshSource    string
+	refreshStrategy  string
+	refreshMinCount  int
 }
 
 var config = new(commandConfig)
 
+const (
+	// errInvalidPrefix is the response body the upstream API uses for a malformed prefix.
+	errInvalidPrefix = "The hash prefix was not in a valid format"
+	// plainTextContentType is the content type the upstream API uses for range responses.
+	plainTextContentType = "text/plain; charset=utf-8"
+)
+
+// Supported values of the --tls-client-auth flag.
+const (
+	tlsClientAuthRequire  = "require"
+	tlsClientAuthOptional = "optional"
+)
+
+// Supported values of the --not-found-behavior flag.
+const (
+	notFound404    = "404"
+	notFoundEmpty  = "empty"
+	notFoundPadded = "padded"
+)
+
 func initFlags() {
 	Command.Flags().StringVar(&config.dsn, "dsn", "", "Database connection string")
+	Command.Flags().StringVar(&config.schema, "schema", dataset.DefaultSchema, "Schema of the instance, as passed to migrate")
 	Command.Flags().StringVar(&config.bindHost, "host", "127.0.0.1", "Host to bind the API on")
 	Command.Flags().IntVar(&config.bindPort, "port", 15000, "Port to bind the API on")
 	Command.Flags().StringSliceVar(&config.schemes, "scheme", []string{"http"}, "Enabled schemes")
+	Command.Flags().StringVar(&config.notFoundBehavior, "not-found-behavior", notFound404, "Response for a prefix without rows: 404, empty or padded")
+	Command.Flags().BoolVar(&config.skipDatasetCheck, "skip-dataset-check", false, "If set, do not check the dataset for missing prefixes on startup")
+	Command.Flags().BoolVar(&config.upstreamFallback, "upstream-fallback", false, "If set, fetch prefixes missing from the database from the upstream API and store them")
+	Command.Flags().StringVar(&config.upstreamURL, "upstream-url", upstream.DefaultBaseURL, "Base URL of the upstream API used by --upstream-fallback")
+	Command.Flags().IntVar(&config.minCount, "min-count", 0, "Only report hashes seen at least this many times")
+	Command.Flags().IntVar(&config.customCount, "custom-count", 0, "Count reported for custom list entries, 0 adds the custom list counts to the HIBP count")
+	Command.Flags().DurationVar(&config.upstreamTimeout, "upstream-timeout", 30*time.Second, "Maximum time a client waits for a prefix to be filled from the upstream API, including retries")
+	Command.Flags().DurationVar(&config.upstreamRequest, "upstream-request-timeout", 5*time.Second, "Maximum time of a single request to the upstream API when filling a prefix")
+	Command.Flags().BoolVar(&config.requireAuth, "require-auth", false, "If set, reject requests without a valid API key or bearer token")
+	Command.Flags().BoolVar(&config.requireAuth, "require-api-key", false, "If set, reject requests without a valid API key or bearer token")
+	Command.Flags().MarkDeprecated("require-api-key", "use --require-auth instead")
+	Command.Flags().DurationVar(&config.apiKeyCacheTTL, "api-key-cache-ttl", 30*time.Second, "How long verified API keys are cached, disabled and revoked keys are rejected after at most this long")
+	Command.Flags().Float64Var(&config.rateLimit, "rate-limit", 0, "Requests per second of a client without a limit of its own, 0 means unlimited")
+	Command.Flags().IntVar(&config.rateBurst, "rate-burst", 0, "Requests a client may send at once, 0 uses --rate-limit rounded up")
+	Command.Flags().StringSliceVar(&config.trustedProxies, "trusted-proxy", nil, "CIDR of a proxy whose X-Forwarded-For header identifies the client, can be repeated")
+	Command.Flags().BoolVar(&config.dailyQuotas, "daily-quotas", false, "If set, enforce the daily quotas of API keys")
+	Command.Flags().StringVar(&config.jwt.JWKSURL, "jwt-jwks-url", "", "JWKS URL of the keys bearer tokens are signed with")
+	Command.Flags().StringVar(&config.jwt.KeyFile, "jwt-key-file", "", "JWKS or PEM file of the keys bearer tokens are signed with")
+	Command.Flags().StringVar(&config.jwt.Issuer, "jwt-issuer", "", "Required iss claim of bearer tokens")
+	Command.Flags().StringVar(&config.jwt.Audience, "jwt-audience", "", "Required aud claim of bearer tokens")
+	Command.Flags().StringSliceVar(&config.jwt.Scopes, "jwt-scope", nil, "Scope bearer tokens must grant, can be repeated")
+	Command.Flags().StringVar(&config.jwt.NameClaim, "jwt-name-claim", "sub", "Claim identifying the client of a bearer token")
+	Command.Flags().StringVar(&config.tlsHost, "tls-host", "", "Host to bind the HTTPS API on, defaults to --host")
+	Command.Flags().IntVar(&config.tlsPort, "tls-port", 15443, "Port to bind the HTTPS API on")
+	Command.Flags().StringVar(&config.tlsCertificate, "tls-certificate", "", "Certificate file of the HTTPS API, enable it with --scheme=https")
+	Command.Flags().StringVar(&config.tlsKey, "tls-key", "", "Private key file of --tls-certificate")
+	Command.Flags().StringVar(&config.tlsCA, "tls-ca", "", "CA file client certificates are verified with, enables mutual TLS")
+	Command.Flags().StringVar(&config.tlsClientAuth, "tls-client-auth", tlsClientAuthRequire, "With --tls-ca, require client certificates or accept connections without one: require or optional")
+	Command.Flags().StringSliceVar(&config.mtls.AllowedSubjects, "tls-allowed-subject", nil, "Common name or distinguished name of an allowed client certificate, can be repeated")
+	Command.Flags().StringSliceVar(&config.mtls.AllowedSANs, "tls-allowed-san", nil, "DNS, email, IP or URI SAN of an allowed client certificate, *.domain matches subdomains, can be repeated")
+	Command.Flags().StringSliceVar(&config.mtls.CRLFiles, "tls-crl", nil, "CRL file client certificates are checked against, reloaded when it changes, can be repeated")
+	Command.Flags().BoolVar(&config.mtls.AllowStaleCRLs, "tls-allow-stale-crl", false, "Keep checking client certificates against a --tls-crl past its next update instead of rejecting them")
+	Command.Flags().StringVar(&config.mtls.OCSP, "tls-ocsp", mtls.OCSPOff, "Check client certificates with their OCSP responder: off, soft or hard")
+	Command.Flags().BoolVar(&config.accessLog, "access-log", false, "If set, log every request with its client to stdout")
+	Command.Flags().StringSliceVar(&config.acmeDomains, "acme-domain", nil, "Domain to obtain a certificate for with ACME instead of --tls-certificate, can be repeated")
+	Command.Flags().StringVar(&config.acmeDirectoryURL, "acme-directory-url", acme.LetsEncryptURL, "Directory URL of the ACME server")
+	Command.Flags().StringVar(&config.acmeEmail, "acme-email", "", "Contact email of the ACME account")
+	Command.Flags().StringVar(&config.acmeCacheDir, "acme-cache-dir", "acme", "Directory the ACME account and certificates are stored in")
+	Command.Flags().StringVar(&config.acmeCAFile, "acme-ca-file", "", "CA file to verify the ACME server with, e.g. of a local Pebble server")
+	Command.Flags().StringVar(&config.adminListen, "admin-listen", "", "Address of the admin API, host:port or unix:PATH, disabled if empty")
+	Command.Flags().StringVar(&config.adminTokenFile, "admin-token-file", "", "File containing the token admin API requests must send, required unless --admin-listen is a unix socket")
+	Command.Flags().StringVar(&config.refreshSchedule, "refresh-schedule", "", "Cron expression to refresh the dataset on while serving, e.g. \"0 3 * * 0\", disabled if empty")
+	Command.Flags().StringVar(&config.refreshSource, "refresh-source", "api", "Data source of --refresh-schedule: api or wordlist:PATH, a wordlist replaces the imported data")
+	Command.Flags().StringVar(&config.refreshStrategy, "refresh-strategy", "swap", "Import strategy of --refresh-schedule: swap replaces all tables, incremental only the ranges that changed upstream")
+	Command.Flags().IntVar(&config.refreshMinCount, "refresh-min-count", 0, "Skip hashes seen fewer times than this when refreshing")
 }
 
 func init() {
@@ -49,6 +169,65 @@ func init() {
 
 func run(cmd *cobra.Command, _ []string) error {
 
+	switch config.notFoundBehavior {
+	case notFound404, notFoundEmpty, notFoundPadded:
+	default:
+		fmt.Fprintln(os.Stderr, "invalid --not-found-behavior", config.notFoundBehavior, "expected one of 404, empty, padded")
+		os.Exit(1)
+	}
+
+	switch config.tlsClientAuth {
+	case tlsClientAuthRequire, tlsClientAuthOptional:
+	default:
+		fmt.Fprintln(os.Stderr, "invalid --tls-client-auth", config.tlsClientAuth, "expected require or optional")
+		os.Exit(1)
+	}
+
+	certVerifier, err := mtls.New(config.mtls)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error configuring client certificate checks", err)
+		os.Exit(1)
+	}
+	if len(config.acmeDomains) > 0 && config.tlsCertificate != "" {
+		fmt.Fprintln(os.Stderr, "--acme-domain and --tls-certificate are mutually exclusive")
+		os.Exit(1)
+	}
+
+	var getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	var acmeManager *autocert.Manager
+	var certs *certReloader
+	switch {
+	case len(config.acmeDomains) > 0:
+		acmeManager, err = newACMEManager(config.acmeDomains, config.acmeDirectoryURL, config.acmeEmail, config.acmeCacheDir, config.acmeCAFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error configuring ACME", err)
+			os.Exit(1)
+		}
+		getCertificate = acmeManager.GetCertificate
+	case config.tlsCertificate != "":
+		certs, err = newCertReloader(config.tlsCertificate, config.tlsKey)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error loading TLS certificate", err)
+			os.Exit(1)
+		}
+		if err := certs.watch(cmd.Context()); err != nil {
+			fmt.Fprintln(os.Stderr, "error watching TLS certificate", err)
+			os.Exit(1)
+		}
+		getCertificate = certs.GetCertificate
+	}
+
+	server.ConfigureTLS = func(tlsConfig *tls.Config) {
+		if getCertificate != nil {
+			useCertificates(tlsConfig, getCertificate)
+		}
+		if acmeManager != nil {
+			// answer tls-alpn-01 challenges on the HTTPS listener
+			tlsConfig.NextProtos = append(tlsConfig.NextProtos, acme.ALPNProto)
+		}
+		certVerifier.Configure(tlsConfig, config.tlsClientAuth == tlsClientAuthOptional)
+	}
+
 	db, err := sqlx.Connect("postgres", config.dsn)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "error establishing database connection", err)
@@ -56,77 +235,307 @@ func run(cmd *cobra.Command, _ []string) error {
 	}
 	defer db.Close()
 
+	layout, err := dataset.LoadLayout(cmd.Context(), db, config.schema)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error loading the table layout", err)
+		os.Exit(1)
+	}
+
+	if !config.skipDatasetCheck {
+		go checkDataset(db, layout)
+	}
+	checkMinCount(db, layout, config.minCount)
+
+	var options checker.Options
+	if config.upstreamFallback {
+		options.Fallback = newUpstreamFallback(db, layout, upstream.New(config.upstreamURL, config.upstreamRequest), config.upstreamTimeout).fill
+	}
+	chk := checker.New(store.New(db, store.Options{
+		MinCount:    config.minCount,
+		CustomCount: config.customCount,
+		Layout:      layout,
+	}), options)
+
 	doc, err := loads.Embedded(server.SwaggerJSON, server.FlatSwaggerJSON)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "error loading Swagger file", err)
 		os.Exit(1)
 	}
 
-	api := restapi.NewSelfHostedHIBPPasswordHashCheckerAPI(doc)
-	api.RangeRestapiRangeSearchHandler = range_restapi.RangeSearchHandlerFunc(func(rsp range_restapi.RangeSearchParams) middleware.Responder {
+	trustedProxies, err := ratelimit.ParseCIDRs(config.trustedProxies)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid --trusted-proxy", err)
+		os.Exit(1)
+	}
 
-		// make sure input is correct:
-		if len(rsp.HashPrefix) != 5 {
-			return range_restapi.NewRangeSearchBadRequest()
-		}
-
-		prefix := strings.ToUpper(rsp.HashPrefix)
-		partitionPrefix := prefix[:2] // Extract first two characters for partition
-
-		// select items from the database using partition_prefix:
-		rows, err := db.NamedQuery(`
-			select "hash", "count" 
-			from hibp 
-			where "partition_prefix" = :partition_prefix 
-			and "prefix" = :prefix`,
-			map[string]interface{}{
-				"partition_prefix": partitionPrefix,
-				"prefix":          prefix,
-			})
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "error while executing SQL query", err)
-			return range_restapi.
-				NewRangeSearchInternalServerError().
-				WithPayload("error while executing SQL query")
-		}
+	keys := apikey.NewAuthenticator(db, layout.Schema, config.apiKeyCacheTTL)
+	limiterOptions := ratelimit.Options{
+		Rate:           config.rateLimit,
+		Burst:          config.rateBurst,
+		TrustedProxies: trustedProxies,
+		Keys:           keys,
+	}
+	if config.dailyQuotas {
+		limiterOptions.Quotas = ratelimit.NewQuotas(db, layout.Schema)
+	}
+	limiter := ratelimit.New(limiterOptions)
 
-		var sb strings.Builder
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	go keys.Run(ctx, time.Minute)
+	go limiter.Run(ctx, time.Minute)
+	go certVerifier.Run(ctx, time.Minute)
 
-		row := model.Row{}
-		foundRows := 0
+	imports := newImportRunner(ctx, config.dsn, layout.Schema)
 
-		// read data out of the SQL result:
-		for rows.Next() {
-			foundRows = foundRows + 1
-			err := rows.StructScan(&row)
+	var refresh *refresher
+	if config.refreshSchedule != "" {
+		if config.refreshSource != "api" && !strings.HasPrefix(config.refreshSource, "wordlist:") {
+			fmt.Fprintln(os.Stderr, "invalid --refresh-source", config.refreshSource, "expected api or wordlist:PATH")
+			os.Exit(1)
+		}
+		switch {
+		case config.refreshStrategy != "swap" && config.refreshStrategy != "incremental":
+			fmt.Fprintln(os.Stderr, "invalid --refresh-strategy", config.refreshStrategy, "expected swap or incremental")
+			os.Exit(1)
+		case config.refreshStrategy == "incremental" && config.refreshSource != "api":
+			fmt.Fprintln(os.Stderr, "--refresh-strategy=incremental requires --refresh-source=api")
+			os.Exit(1)
+		}
+		refresh, err = newRefresher(config.refreshSchedule, imports, config.refreshSource, config.refreshStrategy, config.refreshMinCount)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "invalid --refresh-schedule", err)
+			os.Exit(1)
+		}
+		go refresh.Run(ctx)
+	}
+
+	if config.adminListen != "" {
+		var token string
+		switch {
+		case config.adminTokenFile != "":
+			token, err = readAdminToken(config.adminTokenFile)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, "error while processing response record", err)
-				return range_restapi.
-					NewRangeSearchInternalServerError().
-					WithPayload("error while processing response record")
+				fmt.Fprintln(os.Stderr, "error reading admin token", err)
+				os.Exit(1)
 			}
-			sb.WriteString(fmt.Sprintf("%s:%d\n", row.Hash, row.Count))
+		case !strings.HasPrefix(config.adminListen, adminUnixPrefix):
+			fmt.Fprintln(os.Stderr, "--admin-listen on a TCP address requires --admin-token-file")
+			os.Exit(1)
 		}
 
-		// if nothing found, return 404
-		// according to the data documentation from HiBP, this should never be the case when
-		// full data set is imported, but we should handle this anyway
-		if foundRows == 0 {
-			return range_restapi.NewRangeSearchNotFound()
+		adminServer, err := newAdminServer(&admin{
+			db:          db,
+			layout:      layout,
+			keys:        keys,
+			imports:     imports,
+			refresh:     refresh,
+			certs:       certs,
+			clientCerts: certVerifier,
+			token:       token,
+		}, config.adminListen)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error starting admin API", err)
+			os.Exit(1)
+		}
+		defer adminServer.Shutdown()
+		if socket := strings.TrimPrefix(config.adminListen, adminUnixPrefix); socket != config.adminListen {
+			// the socket was bound under another name, closing it does not remove it
+			defer os.Remove(socket)
 		}
+		go func() {
+			if err := adminServer.Serve(); err != nil {
+				fmt.Fprintln(os.Stderr, "error serving admin API", err)
+			}
+		}()
+	}
 
-		// everything went okay, return records
-		return range_restapi.NewRangeSearchOK().
-			WithPayload(sb.String())
-	})
+	var verifier *jwtauth.Verifier
+	if config.jwt.JWKSURL != "" || config.jwt.KeyFile != "" {
+		verifier, err = jwtauth.New(ctx, config.jwt)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error loading JWT keys", err)
+			os.Exit(1)
+		}
+	}
+
+	api := restapi.NewSelfHostedHIBPPasswordHashCheckerAPI(doc)
+	api.APIKeyAuth = func(token string) (*model.Principal, error) {
+		principal, err := keys.Authenticate(ctx, token)
+		if err == apikey.ErrInvalidKey {
+			return nil, errors.New(http.StatusUnauthorized, "invalid API key")
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error verifying API key", err)
+			return nil, errors.New(http.StatusInternalServerError, "error verifying API key")
+		}
+		return principal, nil
+	}
+	api.BearerAuth = func(token string, _ []string) (*model.Principal, error) {
+		if verifier == nil {
+			return nil, errors.New(http.StatusUnauthorized, "bearer tokens are not accepted")
+		}
+		principal, err := verifier.Verify(ctx, token)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "rejected bearer token", err)
+			return nil, errors.New(http.StatusUnauthorized, "invalid bearer token")
+		}
+		return principal, nil
+	}
+
+	if config.requireAuth {
+		api.APIAuthorizer = runtime.AuthorizerFunc(func(r *http.Request, principal interface{}) error {
+			if principal == nil && mtls.Principal(r) == nil {
+				return errors.New(http.StatusUnauthorized, "missing %s header, bearer token or client certificate", apikey.Header)
+			}
+			return nil
+		})
+	}
+
+	api.RangeRestapiRangeSearchHandler = rangeHandler(chk.Range, config.notFoundBehavior)
 
 	s := server.NewServer(api)
 	s.Host = config.bindHost
 	s.Port = config.bindPort
 	s.EnabledListeners = config.schemes
-	if err := s.Serve(); err != nil {
-		return err
+	s.TLSHost = config.tlsHost
+	s.TLSPort = config.tlsPort
+	s.TLSCertificate = flags.Filename(config.tlsCertificate)
+	s.TLSCertificateKey = flags.Filename(config.tlsKey)
+	s.TLSCACertificate = flags.Filename(config.tlsCA)
+
+	// rate limit after routing and before authentication, like setupMiddlewares:
+	handler := api.Serve(limiter.Middleware)
+	if acmeManager != nil {
+		// answer http-01 challenges on the HTTP listener
+		handler = acmeManager.HTTPHandler(handler)
 	}
+	if config.accessLog {
+		handler = accessLog(handler, trustedProxies)
+	}
+	s.SetHandler(handler)
+	err = s.Serve()
+
+	// keep the usage of the last minute:
+	if err := keys.Flush(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, "error recording API key usage", err)
+	}
+
+	return err
+}
+
+// rangeLookup returns the rows of a hashType prefix sorted by hash, like checker.Checker.Range.
+type rangeLookup func(ctx context.Context, hashType, prefix string) ([]model.Row, error)
+
+// rangeHandler answers range requests from lookup with the status codes,
+// bodies and content type of the upstream API. A prefix without rows is
+// answered according to notFoundBehavior.
+func rangeHandler(lookup rangeLookup, notFoundBehavior string) range_restapi.RangeSearchHandlerFunc {
+	return func(rsp range_restapi.RangeSearchParams, principal *model.Principal) middleware.Responder {
+		if principal == nil {
+			principal = mtls.Principal(rsp.HTTPRequest)
+		}
+		setAccessClient(rsp.HTTPRequest, principalName(principal))
+
+		// make sure input is correct:
+		if !isHashPrefix(rsp.HashPrefix) {
+			return plainText(range_restapi.
+				NewRangeSearchBadRequest().
+				WithPayload(errInvalidPrefix))
+		}
+
+		found, err := lookup(rsp.HTTPRequest.Context(), pwhash.TypeSHA1, rsp.HashPrefix)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "client", principalName(principal), err)
+			return plainText(range_restapi.
+				NewRangeSearchInternalServerError().
+				WithPayload(errorMessage(err)))
+		}
+
+		// according to the data documentation from HiBP, this should never be the case when
+		// full data set is imported, but we should handle this anyway
+		if len(found) == 0 {
+			switch notFoundBehavior {
+			case notFoundEmpty:
+				return plainText(range_restapi.NewRangeSearchOK())
+			case notFoundPadded:
+				return plainText(range_restapi.NewRangeSearchOK().
+					WithPayload(padding(pwhash.TypeSHA1)))
+			default:
+				return range_restapi.NewRangeSearchNotFound()
+			}
+		}
+
+		// everything went okay, return records
+		return plainText(range_restapi.NewRangeSearchOK().
+			WithPayload(formatRange(found)))
+	}
+}
+
+// principalName identifies the client of a request in logs.
+func principalName(principal *model.Principal) string {
+	if principal == nil {
+		return "anonymous"
+	}
+	return principal.Method + ":" + principal.Name
+}
+
+// errorMessage returns the response body for a failed range lookup,
+// without details of the underlying database or network error.
+func errorMessage(err error) string {
+	if lookupErr, ok := err.(*checker.LookupError); ok {
+		return lookupErr.Op
+	}
+	return "error while looking up range"
+}
+
+// formatRange renders rows the way the upstream API does: one SUFFIX:COUNT
+// per line sorted by suffix, separated with CRLF and without a trailing line break.
+func formatRange(rows []model.Row) string {
+	if !sort.SliceIsSorted(rows, func(i, j int) bool { return rows[i].Hash < rows[j].Hash }) {
+		sort.Slice(rows, func(i, j int) bool { return rows[i].Hash < rows[j].Hash })
+	}
+
+	var sb strings.Builder
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteString("\r\n")
+		}
+		sb.WriteString(fmt.Sprintf("%s:%d", row.Hash, row.Count))
+	}
+	return sb.String()
+}
+
+// isHashPrefix reports whether s is a five character hexadecimal hash prefix.
+// Both upper and lower case characters are accepted.
+func isHashPrefix(s string) bool {
+	if len(s) != 5 {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+		case c >= 'A' && c <= 'F':
+		case c >= 'a' && c <= 'f':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// plainTextResponder overrides the negotiated content type so the
+// response headers match the upstream API.
+type plainTextResponder struct {
+	middleware.Responder
+}
+
+func plainText(r middleware.Responder) middleware.Responder {
+	return plainTextResponder{Responder: r}
+}
 
-	return nil
+// WriteResponse to the client
+func (r plainTextResponder) WriteResponse(rw http.ResponseWriter, producer runtime.Producer) {
+	rw.Header().Set(runtime.HeaderContentType, plainTextContentType)
+	r.Responder.WriteResponse(rw, producer)
 }
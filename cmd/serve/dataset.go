@@ -0,0 +1,123 @@
+package serve
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/pwhash"
+)
+
+const (
+	// numPrefixes is the number of five character prefixes.
+	numPrefixes = 16 * 16 * 16 * 16 * 16
+
+	// minPadding and maxPadding bound the number of padding lines,
+	// the same range the upstream API uses for the Add-Padding header.
+	minPadding = 800
+	maxPadding = 1000
+)
+
+// paddingRand generates the padding, handlers share it under paddingMu.
+var (
+	paddingMu   sync.Mutex
+	paddingRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// checkDataset counts the distinct prefixes in every partition of the SHA-1
+// table and warns when some of them are missing, so that an incomplete
+// import does not silently report passwords as safe.
+func checkDataset(db *sqlx.DB, layout dataset.Layout) {
+	started := time.Now()
+	found := 0
+	emptyPartitions := 0
+
+	for _, partition := range layout.PartitionTables(layout.Schema, layout.TableName(pwhash.TypeSHA1)) {
+
+		// walk the prefix index instead of scanning the whole partition:
+		var prefixes int
+		err := db.Get(&prefixes, fmt.Sprintf(`
+			with recursive prefixes as (
+				(select "prefix" from %[1]s order by "prefix" limit 1)
+				union all
+				select (select "prefix" from %[1]s where "prefix" > p."prefix" order by "prefix" limit 1)
+				from prefixes p
+				where p."prefix" is not null
+			)
+			select count("prefix") from prefixes`, partition))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error checking dataset partition", partition, err)
+			return
+		}
+
+		if prefixes == 0 {
+			emptyPartitions++
+		}
+		found += prefixes
+	}
+
+	missing := numPrefixes - found
+	if missing == 0 {
+		fmt.Println("dataset check passed in", time.Since(started).Round(time.Second))
+		return
+	}
+
+	fmt.Fprintf(os.Stderr,
+		"WARNING: dataset is incomplete, %d of %d prefixes are missing (%d empty partitions); "+
+			"passwords in missing ranges will be reported as not pwned\n",
+		missing, numPrefixes, emptyPartitions)
+}
+
+// checkMinCount warns when the last import dropped hashes that the
+// configured minimum count would report.
+func checkMinCount(db *sqlx.DB, layout dataset.Layout, minCount int) {
+	var importMinCount int
+	err := db.Get(&importMinCount, `
+		select "min_count"
+		from `+layout.Qualified("hibp_import")+`
+		where "finished_at" is not null
+		order by "import_id" desc
+		limit 1`)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error reading dataset metadata", err)
+		return
+	}
+
+	if minCount < importMinCount {
+		fmt.Fprintf(os.Stderr,
+			"WARNING: the dataset was imported with --min-count=%d, hashes seen fewer times are missing although --min-count=%d\n",
+			importMinCount, minCount)
+	}
+}
+
+// padding returns between minPadding and maxPadding random hashType suffixes
+// with a count of zero, formatted like an upstream range response.
+func padding(hashType string) string {
+	const hexChars = "0123456789ABCDEF"
+
+	paddingMu.Lock()
+	n := minPadding + paddingRand.Intn(maxPadding-minPadding+1)
+	lines := make([]string, n)
+	suffix := make([]byte, pwhash.Length(hashType)-pwhash.PrefixLength)
+	for i := range lines {
+		for j := range suffix {
+			suffix[j] = hexChars[paddingRand.Intn(len(hexChars))]
+		}
+		lines[i] = string(suffix) + ":0"
+	}
+	paddingMu.Unlock()
+
+	// padded responses must be sorted like real ones
+	sort.Strings(lines)
+	return strings.Join(lines, "\r\n")
+}
@@ -0,0 +1,122 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/model"
+	"github.com/leesalminen/hibp/upstream"
+	"github.com/lib/pq"
+	"golang.org/x/sync/singleflight"
+)
+
+// upstreamFallback fills prefixes missing from the database from the
+// upstream API. Concurrent requests for the same prefix share one fetch.
+type upstreamFallback struct {
+	db      *sqlx.DB
+	layout  dataset.Layout
+	client  *upstream.Client
+	timeout time.Duration
+	group   singleflight.Group
+}
+
+func newUpstreamFallback(db *sqlx.DB, layout dataset.Layout, client *upstream.Client, timeout time.Duration) *upstreamFallback {
+	return &upstreamFallback{
+		db:      db,
+		layout:  layout,
+		client:  client,
+		timeout: timeout,
+	}
+}
+
+// fill fetches the hashType range for prefix, stores it and returns the rows sorted by hash.
+// The fetch keeps running in the background when the timeout expires so that
+// the prefix is still stored for later requests. Every caller gets rows of its
+// own, the checker filters them in place.
+func (f *upstreamFallback) fill(hashType, prefix string) ([]model.Row, error) {
+	ch := f.group.DoChan(hashType+":"+prefix, func() (interface{}, error) {
+		lines, err := f.client.FetchRangeWithRetry(context.Background(), hashType, prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		rows := make([]model.Row, 0, len(lines))
+		for _, line := range lines {
+			suffix, count, err := upstream.ParseLine(line)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "upstream line for prefix", prefix, "skipped,", err)
+				continue
+			}
+			rows = append(rows, model.Row{
+				PartitionPrefix: f.layout.Key(prefix),
+				Prefix:          prefix,
+				Hash:            suffix,
+				Count:           count,
+			})
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i].Hash < rows[j].Hash })
+
+		// the client still gets an answer if the range can't be stored
+		if err := f.store(hashType, prefix, rows); err != nil {
+			fmt.Fprintln(os.Stderr, "error storing prefix", prefix, "filled from upstream", err)
+		}
+
+		return rows, nil
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return append([]model.Row(nil), res.Val.([]model.Row)...), nil
+	case <-time.After(f.timeout):
+		return nil, fmt.Errorf("timed out after %s", f.timeout)
+	}
+}
+
+// store replaces the hashType rows of prefix and marks it as filled lazily.
+func (f *upstreamFallback) store(hashType, prefix string, rows []model.Row) error {
+	tx, err := f.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`delete from `+f.layout.QualifiedTable(hashType)+` where "partition_prefix" = $1 and "prefix" = $2`, f.layout.Key(prefix), prefix); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	stmt, err := tx.Prepare(pq.CopyInSchema(f.layout.Schema, f.layout.TableName(hashType), "partition_prefix", "prefix", "hash", "count"))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.Exec(row.PartitionPrefix, row.Prefix, row.Hash, row.Count); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := stmt.Exec(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		insert into `+f.layout.Qualified("hibp_lazy_prefix")+` ("hash_type", "prefix") values ($1, $2)
+		on conflict ("hash_type", "prefix") do update set "filled_at" = now()`, hashType, prefix); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
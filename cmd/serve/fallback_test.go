@@ -0,0 +1,156 @@
+package serve
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/model"
+	"github.com/leesalminen/hibp/pwhash"
+	"github.com/leesalminen/hibp/upstream"
+)
+
+// unavailable is a database connector that always fails, so filled ranges
+// are answered without being stored.
+type unavailable struct{}
+
+func (unavailable) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("database unavailable")
+}
+
+func (unavailable) Driver() driver.Driver { return nil }
+
+// standIn is an upstream API answering the recorded 21BD1 range. The first
+// failures requests fail with status 503, requests wait for delay first.
+type standIn struct {
+	*httptest.Server
+	requests int32
+	failures int32
+	delay    time.Duration
+}
+
+func newStandIn(t *testing.T, failures int32, delay time.Duration) *standIn {
+	recorded := readRecorded(t, "21BD1.http")
+	s := &standIn{failures: failures, delay: delay}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&s.requests, 1)
+		select {
+		case <-time.After(s.delay):
+		case <-r.Context().Done():
+			return
+		}
+		if r.URL.Path != "/range/21BD1" || r.URL.RawQuery != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if n <= s.failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", recorded.contentType)
+		fmt.Fprint(w, recorded.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestFallback(url string, requestTimeout, timeout time.Duration) *upstreamFallback {
+	db := sqlx.NewDb(sql.OpenDB(unavailable{}), "postgres")
+	return newUpstreamFallback(db, dataset.DefaultLayout, upstream.New(url, requestTimeout), timeout)
+}
+
+func TestFallbackFill(t *testing.T) {
+	recorded := readRecorded(t, "21BD1.http")
+	s := newStandIn(t, 0, 0)
+	f := newTestFallback(s.URL, time.Second, 5*time.Second)
+
+	rows, err := f.fill(pwhash.TypeSHA1, "21BD1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := formatRange(rows); got != recorded.body {
+		t.Errorf("filled %q, want %q", got, recorded.body)
+	}
+	for _, row := range rows {
+		if row.PartitionPrefix != "21" || row.Prefix != "21BD1" {
+			t.Fatalf("row %+v not keyed by 21BD1", row)
+		}
+	}
+}
+
+func TestFallbackSingleFlight(t *testing.T) {
+	s := newStandIn(t, 0, 200*time.Millisecond)
+	f := newTestFallback(s.URL, time.Second, 5*time.Second)
+
+	const callers = 10
+	results := make([][]model.Row, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rows, err := f.fill(pwhash.TypeSHA1, "21BD1")
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = rows
+		}(i)
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&s.requests); n != 1 {
+		t.Errorf("%d upstream requests, want 1", n)
+	}
+	// callers filter their rows in place, they must not share them
+	results[0][0].Count = -1
+	for i := 1; i < callers; i++ {
+		if results[i][0].Count == -1 {
+			t.Fatalf("caller %d shares the rows of caller 0", i)
+		}
+	}
+}
+
+func TestFallbackRetries(t *testing.T) {
+	s := newStandIn(t, 1, 0)
+	f := newTestFallback(s.URL, time.Second, 5*time.Second)
+
+	if _, err := f.fill(pwhash.TypeSHA1, "21BD1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&s.requests); n != 2 {
+		t.Errorf("%d upstream requests, want 2", n)
+	}
+}
+
+func TestFallbackRequestTimeout(t *testing.T) {
+	// every request outlasts the request timeout, the fill gives up first
+	s := newStandIn(t, 0, 500*time.Millisecond)
+	f := newTestFallback(s.URL, 100*time.Millisecond, 300*time.Millisecond)
+
+	started := time.Now()
+	if _, err := f.fill(pwhash.TypeSHA1, "21BD1"); err == nil {
+		t.Fatal("fill succeeded, want a timeout")
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("fill returned after %s, want about 300ms", elapsed)
+	}
+
+	// the request timed out and was retried in the background
+	deadline := time.Now().Add(3 * time.Second)
+	for atomic.LoadInt32(&s.requests) < 2 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&s.requests); n < 2 {
+		t.Errorf("%d upstream requests, want the timed out request retried", n)
+	}
+}
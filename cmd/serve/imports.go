@@ -0,0 +1,141 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// errImportRunning is returned when an import is started while another one runs.
+var errImportRunning = errors.New("an import is already running")
+
+// errImportLocked is the error of an import that did not run because
+// another instance holds the import lock.
+var errImportLocked = errors.New("skipped, another instance is importing")
+
+// errImportInterrupted is the error of an import stopped by a signal.
+var errImportInterrupted = errors.New("interrupted, continue it with data-import --resume")
+
+// Exit statuses of data-import.
+const (
+	// exitImportLocked is the exit status when another import is running.
+	exitImportLocked = 3
+	// exitImportInterrupted is the exit status when the import was stopped by a signal.
+	exitImportInterrupted = 4
+)
+
+// What started an import.
+const (
+	triggerAdmin    = "admin"
+	triggerSchedule = "schedule"
+)
+
+// importRun is the state of the last import started by an importRunner.
+type importRun struct {
+	Running    bool
+	Trigger    string
+	Args       []string
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	Err        error
+}
+
+// importRunner runs hibp data-import as a child process against the database
+// of serve, one import at a time. The child logs to the output of serve.
+type importRunner struct {
+	ctx    context.Context
+	dsn    string
+	schema string
+
+	mu  sync.Mutex
+	run importRun
+}
+
+// newImportRunner creates a runner importing into schema whose imports are
+// interrupted when ctx is done. They finish their current batch and record a
+// checkpoint then.
+func newImportRunner(ctx context.Context, dsn, schema string) *importRunner {
+	return &importRunner{ctx: ctx, dsn: dsn, schema: schema}
+}
+
+// start starts data-import with args in the background.
+func (r *importRunner) start(trigger string, args []string) (importRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.run.Running {
+		return r.run, errImportRunning
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return r.run, err
+	}
+
+	// the DSN holds the database password, it is passed in the environment
+	// because the command line of a process is visible to every user
+	cmd := exec.Command(executable, append([]string{"data-import", "--schema=" + r.schema}, args...)...)
+	cmd.Env = append(os.Environ(), "DSN="+r.dsn)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return r.run, err
+	}
+
+	started := time.Now()
+	r.run = importRun{
+		Running:   true,
+		Trigger:   trigger,
+		Args:      args,
+		StartedAt: &started,
+	}
+	fmt.Println("started data-import", args, "by", trigger)
+
+	exited := make(chan struct{})
+	go func() {
+		select {
+		case <-r.ctx.Done():
+			cmd.Process.Signal(syscall.SIGTERM)
+		case <-exited:
+		}
+	}()
+
+	go func() {
+		err := cmd.Wait()
+		close(exited)
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			switch exitErr.ExitCode() {
+			case exitImportLocked:
+				err = errImportLocked
+			case exitImportInterrupted:
+				err = errImportInterrupted
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "data-import", args, "failed", err)
+		} else {
+			fmt.Println("data-import", args, "finished")
+		}
+
+		finished := time.Now()
+		r.mu.Lock()
+		r.run.Running = false
+		r.run.FinishedAt = &finished
+		r.run.Err = err
+		r.mu.Unlock()
+	}()
+
+	return r.run, nil
+}
+
+// status returns the state of the last import.
+func (r *importRunner) status() importRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.run
+}
@@ -0,0 +1,236 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/runtime"
+	"github.com/leesalminen/hibp/api/server/restapi/range_restapi"
+	"github.com/leesalminen/hibp/model"
+	"github.com/leesalminen/hibp/pwhash"
+	"github.com/leesalminen/hibp/upstream"
+)
+
+// recorded is an upstream range response from testdata/upstream.
+type recorded struct {
+	status      int
+	contentType string
+	body        string
+}
+
+func readRecorded(t *testing.T, name string) recorded {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", "upstream", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	resp, err := http.ReadResponse(bufio.NewReader(f), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return recorded{
+		status:      resp.StatusCode,
+		contentType: resp.Header.Get("Content-Type"),
+		body:        string(body),
+	}
+}
+
+// rowsOf parses a range response body into rows in reverse order,
+// so that responses are only sorted if the handler sorts them.
+func rowsOf(t *testing.T, prefix, body string) []model.Row {
+	t.Helper()
+
+	lines := strings.Split(body, "\r\n")
+	rows := make([]model.Row, 0, len(lines))
+	for i := len(lines) - 1; i >= 0; i-- {
+		suffix, count, err := upstream.ParseLine(lines[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, model.Row{Prefix: prefix, Hash: suffix, Count: count})
+	}
+	return rows
+}
+
+// serveRange runs the range handler for prefix and returns the recorded response.
+func serveRange(lookup rangeLookup, notFoundBehavior, prefix string) *httptest.ResponseRecorder {
+	params := range_restapi.NewRangeSearchParams()
+	params.HTTPRequest = httptest.NewRequest(http.MethodGet, "/range/"+prefix, nil)
+	params.HashPrefix = prefix
+
+	rec := httptest.NewRecorder()
+	rangeHandler(lookup, notFoundBehavior)(params, nil).WriteResponse(rec, runtime.TextProducer())
+	return rec
+}
+
+func TestIsHashPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		valid  bool
+	}{
+		{"21BD1", true},
+		{"21bd1", true},
+		{"21bD1", true},
+		{"00000", true},
+		{"FFFFF", true},
+		{"", false},
+		{"21BD", false},
+		{"21BD12", false},
+		{"ZZZZZ", false},
+		{"21BG1", false},
+		{"21BD ", false},
+		{"21-D1", false},
+		{"２1BD1", false},
+	}
+
+	for _, test := range tests {
+		if valid := isHashPrefix(test.prefix); valid != test.valid {
+			t.Errorf("isHashPrefix(%q) = %v, want %v", test.prefix, valid, test.valid)
+		}
+	}
+}
+
+func TestRangeConformance(t *testing.T) {
+	stored := readRecorded(t, "21BD1.http")
+	rows := rowsOf(t, "21BD1", stored.body)
+
+	lookup := func(_ context.Context, hashType, prefix string) ([]model.Row, error) {
+		if hashType != pwhash.TypeSHA1 {
+			t.Errorf("looked up %s, want %s", hashType, pwhash.TypeSHA1)
+		}
+		// like checker.Checker.Range:
+		if strings.ToUpper(prefix) != "21BD1" {
+			return nil, nil
+		}
+		return append([]model.Row(nil), rows...), nil
+	}
+
+	tests := []struct {
+		name     string
+		prefix   string
+		recorded string
+	}{
+		{"upper case", "21BD1", "21BD1.http"},
+		{"lower case", "21bd1", "21BD1.http"},
+		{"mixed case", "21bD1", "21BD1.http"},
+		{"not hexadecimal", "ZZZZZ", "invalid.http"},
+		{"too short", "21BD", "invalid.http"},
+		{"too long", "21BD12", "invalid.http"},
+		{"empty", "", "invalid.http"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want := readRecorded(t, test.recorded)
+			rec := serveRange(lookup, notFound404, test.prefix)
+
+			if rec.Code != want.status {
+				t.Errorf("status %d, want %d", rec.Code, want.status)
+			}
+			if contentType := rec.Header().Get("Content-Type"); contentType != want.contentType {
+				t.Errorf("content type %q, want %q", contentType, want.contentType)
+			}
+			if body := rec.Body.String(); body != want.body {
+				t.Errorf("body %q, want %q", body, want.body)
+			}
+		})
+	}
+}
+
+func TestRangeNotFound(t *testing.T) {
+	lookup := func(context.Context, string, string) ([]model.Row, error) {
+		return nil, nil
+	}
+
+	t.Run("404", func(t *testing.T) {
+		rec := serveRange(lookup, notFound404, "21BD1")
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("status %d, want %d", rec.Code, http.StatusNotFound)
+		}
+		if rec.Body.Len() != 0 {
+			t.Errorf("body %q, want none", rec.Body.String())
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		rec := serveRange(lookup, notFoundEmpty, "21BD1")
+		if rec.Code != http.StatusOK {
+			t.Errorf("status %d, want %d", rec.Code, http.StatusOK)
+		}
+		if contentType := rec.Header().Get("Content-Type"); contentType != plainTextContentType {
+			t.Errorf("content type %q, want %q", contentType, plainTextContentType)
+		}
+		if rec.Body.Len() != 0 {
+			t.Errorf("body %q, want none", rec.Body.String())
+		}
+	})
+
+	t.Run("padded", func(t *testing.T) {
+		rec := serveRange(lookup, notFoundPadded, "21BD1")
+		if rec.Code != http.StatusOK {
+			t.Errorf("status %d, want %d", rec.Code, http.StatusOK)
+		}
+		if contentType := rec.Header().Get("Content-Type"); contentType != plainTextContentType {
+			t.Errorf("content type %q, want %q", contentType, plainTextContentType)
+		}
+
+		body := rec.Body.String()
+		if strings.HasSuffix(body, "\r\n") {
+			t.Error("padded body ends with a line break")
+		}
+		lines := strings.Split(body, "\r\n")
+		if len(lines) < minPadding || len(lines) > maxPadding {
+			t.Errorf("%d padding lines, want %d to %d", len(lines), minPadding, maxPadding)
+		}
+		if !sort.StringsAreSorted(lines) {
+			t.Error("padding lines are not sorted")
+		}
+		for _, line := range lines {
+			suffix, count, err := upstream.ParseLine(line)
+			if err != nil || count != 0 || !pwhash.Valid(pwhash.TypeSHA1, "21BD1"+suffix) {
+				t.Fatalf("invalid padding line %q", line)
+			}
+		}
+	})
+}
+
+func TestFormatRange(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []model.Row
+		want string
+	}{
+		{"no rows", nil, ""},
+		{"one row", []model.Row{{Hash: "0018A45C4D1DEF81644B54AB7F969B88D65", Count: 1}}, "0018A45C4D1DEF81644B54AB7F969B88D65:1"},
+		{"sorted", []model.Row{
+			{Hash: "00D4F6E8FA6EECAD2A3AA415EEC418D38EC", Count: 2},
+			{Hash: "0018A45C4D1DEF81644B54AB7F969B88D65", Count: 1},
+			{Hash: "011053FD0102E94D6AE2F8B83D76FAF94F6", Count: 3},
+		}, "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n00D4F6E8FA6EECAD2A3AA415EEC418D38EC:2\r\n011053FD0102E94D6AE2F8B83D76FAF94F6:3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := formatRange(test.rows); got != test.want {
+				t.Errorf("formatRange() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
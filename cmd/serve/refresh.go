@@ -0,0 +1,78 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// refresher starts a swap or incremental import on a cron schedule. Serving
+// continues on the old data while the import runs, and replicas sharing the
+// database skip the refresh while one of them holds the import lock.
+type refresher struct {
+	spec     string
+	schedule cron.Schedule
+	imports  *importRunner
+	args     []string
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// newRefresher parses spec, a standard five field cron expression or a
+// descriptor like @weekly, evaluated in local time.
+func newRefresher(spec string, imports *importRunner, source, strategy string, minCount int) (*refresher, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{
+		"--source=" + source,
+		"--strategy=" + strategy,
+		"--min-count=" + strconv.Itoa(minCount),
+	}
+	if source != "api" {
+		// a scheduled wordlist refresh is meant to replace the data
+		args = append(args, "--replace-data")
+	}
+	return &refresher{
+		spec:     spec,
+		schedule: schedule,
+		imports:  imports,
+		args:     args,
+	}, nil
+}
+
+// Run starts imports on schedule until ctx is done.
+func (r *refresher) Run(ctx context.Context) {
+	for {
+		next := r.schedule.Next(time.Now())
+		r.mu.Lock()
+		r.next = next
+		r.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := r.imports.start(triggerSchedule, r.args); err != nil {
+			fmt.Fprintln(os.Stderr, "scheduled refresh skipped,", err)
+		}
+	}
+}
+
+// nextRun returns when the next refresh starts.
+func (r *refresher) nextRun() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.next
+}
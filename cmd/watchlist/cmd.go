@@ -0,0 +1,264 @@
+package watchlist
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/pwhash"
+	"github.com/leesalminen/hibp/watchlist"
+	"github.com/spf13/cobra"
+
+	// import postgres
+	_ "github.com/lib/pq"
+)
+
+// Command is the cobra command.
+var Command = &cobra.Command{
+	Use:   "watchlist",
+	Short: "Manage hashes re-checked after every import",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+		os.Exit(1)
+	},
+}
+
+var addCommand = &cobra.Command{
+	Use:   "add [hash]",
+	Short: "Register a hash under an opaque ID",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runAdd,
+}
+
+var removeCommand = &cobra.Command{
+	Use:   "remove id...",
+	Short: "Remove hashes from the watchlist",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runRemove,
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List watched hashes and their last check",
+	RunE:  runList,
+}
+
+var checkCommand = &cobra.Command{
+	Use:   "check",
+	Short: "Check the watchlist against the current dataset",
+	RunE:  runCheck,
+}
+
+type commandConfig struct {
+	dsn      string
+	schema   string
+	id       string
+	hashType string
+	file     string
+	report   string
+	webhook  string
+}
+
+var config = new(commandConfig)
+
+func initFlags() {
+	Command.PersistentFlags().StringVar(&config.dsn, "dsn", "", "Database connection string")
+	Command.PersistentFlags().StringVar(&config.schema, "schema", dataset.DefaultSchema, "Schema of the instance, as passed to migrate")
+
+	addCommand.Flags().StringVar(&config.id, "id", "", "Opaque ID of the hash")
+	addCommand.Flags().StringVar(&config.hashType, "type", pwhash.TypeSHA1, "Hash type: sha1 or ntlm")
+	addCommand.Flags().StringVar(&config.file, "file", "", "Read id,hash pairs from a CSV file, - for stdin")
+
+	checkCommand.Flags().StringVar(&config.report, "report", "", "Write the JSON report of newly pwned IDs to this file")
+	checkCommand.Flags().StringVar(&config.webhook, "webhook", "", "POST the JSON report to this URL when IDs are newly pwned")
+}
+
+func init() {
+	initFlags()
+	Command.AddCommand(addCommand)
+	Command.AddCommand(removeCommand)
+	Command.AddCommand(listCommand)
+	Command.AddCommand(checkCommand)
+}
+
+type watchedHash struct {
+	ID         string     `db:"watch_id"`
+	HashType   string     `db:"hash_type"`
+	Prefix     string     `db:"prefix"`
+	Hash       string     `db:"hash"`
+	PwnedCount int        `db:"pwned_count"`
+	CheckedAt  *time.Time `db:"checked_at"`
+}
+
+// connect connects to the database and loads the layout of --schema.
+func connect(ctx context.Context) (*sqlx.DB, dataset.Layout) {
+	db, err := sqlx.Connect("postgres", config.dsn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error establishing database connection", err)
+		os.Exit(1)
+	}
+	layout, err := dataset.LoadLayout(ctx, db, config.schema)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error loading the table layout", err)
+		os.Exit(1)
+	}
+	return db, layout
+}
+
+// readPairs returns the id and hash pairs given on the command line or in --file.
+func readPairs(args []string) ([][2]string, error) {
+	if config.file == "" {
+		if config.id == "" || len(args) != 1 {
+			return nil, fmt.Errorf("either --id and a hash or --file is required")
+		}
+		return [][2]string{{config.id, args[0]}}, nil
+	}
+
+	var r io.Reader = os.Stdin
+	if config.file != "-" {
+		f, err := os.Open(config.file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make([][2]string, 0, len(records))
+	for _, record := range records {
+		if len(record) != 2 {
+			return nil, fmt.Errorf("expected id,hash but got %d fields", len(record))
+		}
+		pairs = append(pairs, [2]string{record[0], record[1]})
+	}
+	return pairs, nil
+}
+
+func runAdd(cmd *cobra.Command, args []string) error {
+	pairs, err := readPairs(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error reading hashes", err)
+		os.Exit(1)
+	}
+
+	db, layout := connect(cmd.Context())
+	defer db.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range pairs {
+		id, hash := pair[0], strings.TrimSpace(pair[1])
+		if !pwhash.Valid(config.hashType, hash) {
+			tx.Rollback()
+			fmt.Fprintf(os.Stderr, "hash of %s is not a valid %s hash\n", id, config.hashType)
+			os.Exit(1)
+		}
+
+		prefix, suffix := pwhash.Split(hash)
+		_, err := tx.Exec(`
+			insert into `+layout.Qualified("hibp_watchlist")+` ("watch_id", "hash_type", "prefix", "hash")
+			values ($1, $2, $3, $4)
+			on conflict ("watch_id") do update set
+				"hash_type" = excluded."hash_type",
+				"prefix" = excluded."prefix",
+				"hash" = excluded."hash",
+				"pwned_count" = 0,
+				"pwned_import" = null,
+				"checked_at" = null`,
+			id, config.hashType, prefix, suffix)
+		if err != nil {
+			tx.Rollback()
+			fmt.Fprintln(os.Stderr, "error adding watched hash", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Watching %d hashes\n", len(pairs))
+	return nil
+}
+
+func runRemove(cmd *cobra.Command, args []string) error {
+	db, layout := connect(cmd.Context())
+	defer db.Close()
+
+	var removed int64
+	for _, id := range args {
+		res, err := db.Exec(`delete from `+layout.Qualified("hibp_watchlist")+` where "watch_id" = $1`, id)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error removing watched hash", err)
+			os.Exit(1)
+		}
+		n, _ := res.RowsAffected()
+		removed += n
+	}
+
+	fmt.Printf("Removed %d hashes\n", removed)
+	return nil
+}
+
+func runList(cmd *cobra.Command, _ []string) error {
+	db, layout := connect(cmd.Context())
+	defer db.Close()
+
+	var watched []watchedHash
+	err := db.Select(&watched, `
+		select "watch_id", "hash_type", "prefix", "hash", "pwned_count", "checked_at"
+		from `+layout.Qualified("hibp_watchlist")+`
+		order by "watch_id"`)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error listing watched hashes", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tPREFIX\tCOUNT\tCHECKED")
+	for _, h := range watched {
+		checked := "never"
+		if h.CheckedAt != nil {
+			checked = h.CheckedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", h.ID, h.HashType, h.Prefix, h.PwnedCount, checked)
+	}
+	return w.Flush()
+}
+
+func runCheck(cmd *cobra.Command, _ []string) error {
+	db, layout := connect(cmd.Context())
+	defer db.Close()
+
+	report, err := watchlist.Check(db, layout, 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error checking watchlist", err)
+		os.Exit(1)
+	}
+
+	if err := watchlist.Publish(report, config.report, config.webhook); err != nil {
+		fmt.Fprintln(os.Stderr, "error publishing watchlist report", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Checked %d hashes, %d newly pwned\n", report.Checked, len(report.NewlyPwned))
+	for _, match := range report.NewlyPwned {
+		fmt.Printf("%s\t%s\t%d\n", match.ID, match.HashType, match.Count)
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+// Package dataset creates the partitioned hash tables and replaces them
+// atomically after a refresh.
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// refreshSuffix is appended to the layout schema to name the schema a
+	// refresh imports into before its tables are swapped in.
+	refreshSuffix = "_refresh"
+	// retiredSuffix names the schema of the replaced tables until they are dropped.
+	retiredSuffix = "_retired"
+)
+
+// ImportLock is the key of the advisory lock held while data-import runs,
+// so that only one import runs per schema at a time. The lock is taken
+// with the hash of the schema name as second key.
+const ImportLock = 0x68696270
+
+// RefreshSchema returns the schema a refresh imports into.
+func (l Layout) RefreshSchema() string {
+	return l.Schema + refreshSuffix
+}
+
+// retiredSchema returns the schema holding the replaced tables during a swap.
+func (l Layout) retiredSchema() string {
+	return l.Schema + retiredSuffix
+}
+
+// TableSchema creates the hash table in schema with the partitions and
+// prefix indexes of the layout. SHA-1 and NTLM hashes share the layout.
+// Every row keeps the --source of the import that wrote it, so that the
+// HIBP rows and the rows of every wordlist are merged separately.
+func (l Layout) TableSchema(schema, table string) string {
+	partitionBy := "LIST (partition_prefix)"
+	if l.Method == PartitionHash {
+		partitionBy = "HASH (prefix)"
+	}
+
+	baseSchema := fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %[1]s.%[2]s (
+	row_id serial NOT NULL,
+	partition_prefix varchar(%[3]d) NOT NULL,
+	prefix varchar(5) NOT NULL,
+	hash varchar(40) NOT NULL,
+	count integer NOT NULL,
+	CONSTRAINT %[2]s_pkey PRIMARY KEY (row_id, partition_prefix, prefix)
+) PARTITION BY %[4]s;
+ALTER TABLE %[1]s.%[2]s
+	ADD COLUMN IF NOT EXISTS first_seen_at timestamptz DEFAULT now(),
+	ADD COLUMN IF NOT EXISTS first_seen_import integer,
+	ADD COLUMN IF NOT EXISTS count_changed_at timestamptz,
+	ADD COLUMN IF NOT EXISTS count_changed_import integer,
+	ADD COLUMN IF NOT EXISTS previous_count integer,
+	ADD COLUMN IF NOT EXISTS source text NOT NULL DEFAULT 'api';
+`, schema, table, l.KeyLength(), partitionBy)
+
+	var partitions, indexes strings.Builder
+
+	for _, p := range l.partitions() {
+		// Create partition
+		partitions.WriteString(fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %[1]s.%[2]s_%[3]s PARTITION OF %[1]s.%[2]s FOR VALUES %[4]s;\n",
+			schema, table, p.name, p.bound,
+		))
+
+		// Create index for the partition
+		indexes.WriteString(prefixIndexSchema(schema, table, p) + ";\n")
+	}
+
+	return baseSchema + partitions.String() + indexes.String()
+}
+
+// prefixIndexSchema creates the prefix index of a partition.
+func prefixIndexSchema(schema, table string, p partition) string {
+	return fmt.Sprintf("CREATE INDEX IF NOT EXISTS %[2]s_%[3]s ON %[1]s.%[2]s_%[4]s (prefix)", schema, table, p.index, p.name)
+}
+
+// PrepareFastLoad drops the prefix indexes of the partitions of table and
+// makes them UNLOGGED, so that a bulk load neither maintains the indexes nor
+// writes WAL. The partitions should be empty. SetLogged and CreateIndexes
+// undo it; until then a crash of Postgres truncates the partitions.
+func PrepareFastLoad(ctx context.Context, db *sqlx.DB, layout Layout, schema, table string) error {
+	var ddl strings.Builder
+	for _, p := range layout.partitions() {
+		fmt.Fprintf(&ddl, "DROP INDEX IF EXISTS %[1]s.%[2]s_%[3]s;\nALTER TABLE %[1]s.%[2]s_%[4]s SET UNLOGGED;\n", schema, table, p.index, p.name)
+	}
+	_, err := db.ExecContext(ctx, ddl.String())
+	return err
+}
+
+// SetLogged makes the partitions of table LOGGED again after a fast load,
+// workers partitions at a time.
+func SetLogged(ctx context.Context, db *sqlx.DB, layout Layout, schema, table string, workers int) error {
+	return forEachPartition(ctx, layout, workers, func(p partition) error {
+		_, err := db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s.%s_%s SET LOGGED", schema, table, p.name))
+		return err
+	})
+}
+
+// CreateIndexes builds the missing prefix indexes of the partitions of table,
+// workers partitions at a time.
+func CreateIndexes(ctx context.Context, db *sqlx.DB, layout Layout, schema, table string, workers int) error {
+	return forEachPartition(ctx, layout, workers, func(p partition) error {
+		_, err := db.ExecContext(ctx, prefixIndexSchema(schema, table, p))
+		return err
+	})
+}
+
+// forEachPartition calls fn for every partition of layout, workers at a
+// time, and returns the first error. No partition is started after an error.
+func forEachPartition(ctx context.Context, layout Layout, workers int, fn func(p partition) error) error {
+	partitions := make(chan partition)
+	errs := make(chan error, workers)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			var err error
+			for partition := range partitions {
+				if err == nil {
+					if err = fn(partition); err != nil {
+						cancel()
+					}
+				}
+			}
+			errs <- err
+		}()
+	}
+
+dispatch:
+	for _, p := range layout.partitions() {
+		select {
+		case partitions <- p:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(partitions)
+
+	var first error
+	for w := 0; w < workers; w++ {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+		}
+	}
+	if first == nil {
+		first = ctx.Err()
+	}
+	return first
+}
+
+// CreateRefreshTables creates empty copies of tables in the refresh schema,
+// dropping the leftovers of an earlier refresh that did not finish.
+func CreateRefreshTables(ctx context.Context, db *sqlx.DB, layout Layout, tables ...string) error {
+	refresh := layout.RefreshSchema()
+	ddl := "DROP SCHEMA IF EXISTS " + refresh + " CASCADE;\nCREATE SCHEMA " + refresh + ";\n"
+	for _, table := range tables {
+		ddl += layout.TableSchema(refresh, table)
+	}
+	_, err := db.ExecContext(ctx, ddl)
+	return err
+}
+
+// Swap replaces tables in the layout schema and their partitions with the
+// ones in the refresh schema in one transaction, so that readers see either the
+// old or the new data. Prefixes filled from upstream and the versions of
+// incrementally imported ranges are forgotten, the replaced tables are dropped.
+func Swap(ctx context.Context, db *sqlx.DB, layout Layout, hashTypes map[string]string) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	retired, refresh := layout.retiredSchema(), layout.RefreshSchema()
+
+	// a failed drop may have left the tables of an earlier swap behind:
+	if _, err := tx.ExecContext(ctx, "DROP SCHEMA IF EXISTS "+retired+" CASCADE;\nCREATE SCHEMA "+retired); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for hashType, table := range hashTypes {
+		if err := moveTable(ctx, tx, layout.Schema, retired, table); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := moveTable(ctx, tx, refresh, layout.Schema, table); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `delete from `+layout.Qualified("hibp_lazy_prefix")+` where "hash_type" = $1`, hashType); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `delete from `+layout.Qualified("hibp_range_version")+` where "hash_type" = $1`, hashType); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, "DROP SCHEMA "+retired+" CASCADE;\nDROP SCHEMA IF EXISTS "+refresh+" CASCADE")
+	return err
+}
+
+// moveTable moves a partitioned table and its partitions from one schema to
+// another. Indexes, constraints and the row_id sequence move along.
+func moveTable(ctx context.Context, tx *sqlx.Tx, from, to, table string) error {
+	var partitions []string
+	err := tx.SelectContext(ctx, &partitions, `
+		select c."relname"
+		from pg_inherits i
+		join pg_class c on c."oid" = i."inhrelid"
+		where i."inhparent" = ($1 || '.' || $2)::regclass`, from, table)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range append(partitions, table) {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s.%s SET SCHEMA %s", from, name, to)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrImportLocked is returned by LockImport while another import holds the lock.
+var ErrImportLocked = errors.New("another import is running")
+
+// LockImport takes the import lock of schema on a connection of its own.
+// The lock is held until the connection is closed.
+func LockImport(ctx context.Context, db *sqlx.DB, schema string) (*sql.Conn, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `select pg_try_advisory_lock($1, hashtext($2))`, ImportLock, schema).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrImportLocked
+	}
+	return conn, nil
+}
+
+// ImportLocked reports whether any instance holds the import lock of schema.
+func ImportLocked(ctx context.Context, db *sqlx.DB, schema string) (bool, error) {
+	// advisory locks on two keys are listed with objsubid 2:
+	var locked bool
+	err := db.GetContext(ctx, &locked, `
+		select exists (
+			select 1 from pg_locks
+			where "locktype" = 'advisory' and "granted"
+			and "database" = (select "oid" from pg_database where "datname" = current_database())
+			and "classid" = $1::oid and "objid" = hashtext($2)::oid and "objsubid" = 2
+		)`, ImportLock, schema)
+	return locked, err
+}
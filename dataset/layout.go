@@ -0,0 +1,276 @@
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/pwhash"
+	"github.com/lib/pq"
+)
+
+// Partitioning methods of the hash tables.
+const (
+	// PartitionList partitions by partition_prefix, the first characters of
+	// the hash prefix, so every partition holds a contiguous prefix range.
+	PartitionList = "list"
+	// PartitionHash partitions by a hash of the prefix, which spreads the
+	// prefixes evenly regardless of the partition count.
+	PartitionHash = "hash"
+)
+
+// Layout is where the hash tables are and how they are partitioned.
+// migrate records it in the hibp_layout table of its schema, the importer
+// and serve load it from there, so they agree on table names and partition
+// keys. Every instance keeps all its tables in a schema of its own.
+type Layout struct {
+	// Schema holds the hash tables and the tables of the instance.
+	Schema string `db:"schema_name"`
+	// Table is the SHA-1 table, the NTLM table gets the _ntlm suffix.
+	Table string `db:"table_name"`
+	// Partitions is the number of partitions of each table: 16, 256 or 4096.
+	Partitions int `db:"partitions"`
+	// Method is PartitionList or PartitionHash.
+	Method string `db:"method"`
+}
+
+// DefaultSchema holds the tables of databases migrated without --schema.
+const DefaultSchema = "public"
+
+// DefaultLayout is the layout of databases migrated before it was configurable.
+var DefaultLayout = Layout{Schema: DefaultSchema, Table: "hibp", Partitions: 256, Method: PartitionList}
+
+// identifier matches the schema and table names accepted unquoted.
+var identifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Validate checks the layout before it is used in SQL.
+func (l Layout) Validate() error {
+	switch l.Partitions {
+	case 16, 256, 4096:
+	default:
+		return fmt.Errorf("invalid partition count %d, expected 16, 256 or 4096", l.Partitions)
+	}
+	if l.Method != PartitionList && l.Method != PartitionHash {
+		return fmt.Errorf("invalid partitioning method %q, expected list or hash", l.Method)
+	}
+	if !identifier.MatchString(l.Schema) {
+		return fmt.Errorf("invalid schema name %q", l.Schema)
+	}
+	if strings.HasSuffix(l.Schema, refreshSuffix) || strings.HasSuffix(l.Schema, retiredSuffix) {
+		return fmt.Errorf("schema %s is reserved for refreshes", l.Schema)
+	}
+	// Postgres truncates longer names, the longest one is an NTLM partition index:
+	if !identifier.MatchString(l.Table) || len(l.Table+"_ntlm_prefix_idx_000") > 63 {
+		return fmt.Errorf("invalid table name %q", l.Table)
+	}
+	return nil
+}
+
+// String describes the layout for messages.
+func (l Layout) String() string {
+	return fmt.Sprintf("%s.%s with %d %s partitions", l.Schema, l.Table, l.Partitions, l.Method)
+}
+
+// KeyLength returns the number of hash prefix characters stored in partition_prefix.
+func (l Layout) KeyLength() int {
+	switch l.Partitions {
+	case 16:
+		return 1
+	case 4096:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// Key returns the partition_prefix of a five character hash prefix.
+func (l Layout) Key(prefix string) string {
+	return prefix[:l.KeyLength()]
+}
+
+// KeyIndex returns the partition_prefix of prefix as a number between
+// 0 and Partitions-1. With list partitioning, it is the partition of prefix.
+func (l Layout) KeyIndex(prefix string) (int, error) {
+	i, err := strconv.ParseUint(l.Key(prefix), 16, 12)
+	return int(i), err
+}
+
+// HashPartitions returns the partition of every five character hash prefix
+// with hash partitioning, indexed by the prefix as a number. Postgres hashes
+// the prefixes into a temporary table partitioned like the hash tables, so
+// the partitions match those of the hash tables exactly.
+func HashPartitions(ctx context.Context, db *sqlx.DB, l Layout) ([]uint16, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var ddl strings.Builder
+	ddl.WriteString("CREATE TEMPORARY TABLE hibp_partition_probe (prefix varchar(5) NOT NULL) PARTITION BY HASH (prefix) ON COMMIT DROP;\n")
+	for _, p := range l.partitions() {
+		fmt.Fprintf(&ddl, "CREATE TEMPORARY TABLE hibp_partition_probe_%s PARTITION OF hibp_partition_probe FOR VALUES %s ON COMMIT DROP;\n", p.name, p.bound)
+	}
+	if _, err := tx.ExecContext(ctx, ddl.String()); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		insert into hibp_partition_probe ("prefix")
+		select upper(lpad(to_hex(i), 5, '0')) from generate_series(0, 1048575) i`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		select p."prefix", c."relname"
+		from hibp_partition_probe p
+		join pg_class c on c."oid" = p."tableoid"`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	partitions := make([]uint16, 1<<20)
+	for rows.Next() {
+		var prefix, relname string
+		if err := rows.Scan(&prefix, &relname); err != nil {
+			return nil, err
+		}
+		i, err := strconv.ParseUint(prefix, 16, 20)
+		if err != nil {
+			return nil, err
+		}
+		partition, err := strconv.ParseUint(strings.TrimPrefix(relname, "hibp_partition_probe_hash_"), 10, 16)
+		if err != nil {
+			return nil, err
+		}
+		partitions[i] = uint16(partition)
+	}
+	return partitions, rows.Err()
+}
+
+// TableName returns the unqualified table holding the hashes of hashType.
+func (l Layout) TableName(hashType string) string {
+	if hashType == pwhash.TypeNTLM {
+		return l.Table + "_ntlm"
+	}
+	return l.Table
+}
+
+// QualifiedTable returns the table holding the hashes of hashType, qualified with Schema.
+func (l Layout) QualifiedTable(hashType string) string {
+	return l.Schema + "." + l.TableName(hashType)
+}
+
+// Qualified returns table qualified with Schema.
+func (l Layout) Qualified(table string) string {
+	return l.Schema + "." + table
+}
+
+// Tables returns the unqualified tables by hash type.
+func (l Layout) Tables() map[string]string {
+	return map[string]string{
+		pwhash.TypeSHA1: l.TableName(pwhash.TypeSHA1),
+		pwhash.TypeNTLM: l.TableName(pwhash.TypeNTLM),
+	}
+}
+
+// partition is one partition of a hash table. The table name is prepended
+// to name and index.
+type partition struct {
+	name  string
+	index string
+	bound string
+}
+
+// partitions returns the partitions of every hash table in order.
+func (l Layout) partitions() []partition {
+	parts := make([]partition, l.Partitions)
+	for i := range parts {
+		if l.Method == PartitionHash {
+			parts[i] = partition{
+				name:  fmt.Sprintf("hash_%d", i),
+				index: fmt.Sprintf("hash_idx_%d", i),
+				bound: fmt.Sprintf("WITH (MODULUS %d, REMAINDER %d)", l.Partitions, i),
+			}
+			continue
+		}
+		key := fmt.Sprintf("%0*X", l.KeyLength(), i)
+		parts[i] = partition{
+			name:  "prefix_" + key,
+			index: "prefix_idx_" + key,
+			bound: "IN ('" + key + "')",
+		}
+	}
+	return parts
+}
+
+// PartitionTables returns the partitions of table in schema, qualified.
+func (l Layout) PartitionTables(schema, table string) []string {
+	parts := l.partitions()
+	names := make([]string, len(parts))
+	for i, p := range parts {
+		names[i] = schema + "." + table + "_" + p.name
+	}
+	return names
+}
+
+// LayoutSchema records the layout of the hash tables in schema. It holds one row.
+func LayoutSchema(schema string) string {
+	return `
+CREATE TABLE IF NOT EXISTS ` + schema + `.hibp_layout (
+	layout_id boolean NOT NULL DEFAULT true CHECK (layout_id),
+	schema_name text NOT NULL,
+	table_name text NOT NULL,
+	partitions integer NOT NULL,
+	method varchar(4) NOT NULL,
+	CONSTRAINT hibp_layout_pkey PRIMARY KEY (layout_id)
+);
+`
+}
+
+// LoadLayout returns the layout recorded in schema, DefaultLayout in schema
+// if there is none.
+func LoadLayout(ctx context.Context, db *sqlx.DB, schema string) (Layout, error) {
+	l := DefaultLayout
+	l.Schema = schema
+	if !identifier.MatchString(schema) {
+		return l, fmt.Errorf("invalid schema name %q", schema)
+	}
+
+	err := db.GetContext(ctx, &l, `select "schema_name", "table_name", "partitions", "method" from `+l.Qualified("hibp_layout"))
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "42P01" {
+		// undefined_table, the database was migrated by an earlier version
+		return l, nil
+	}
+	if err == sql.ErrNoRows {
+		return l, nil
+	}
+	if err != nil {
+		return l, err
+	}
+	if l.Schema != schema {
+		// recorded by a version that kept the other tables in public
+		return l, fmt.Errorf("the layout in %s records the hash tables in schema %s, run migrate --schema=%s and import again", schema, l.Schema, l.Schema)
+	}
+	return l, l.Validate()
+}
+
+// SaveLayout records l in its schema, replacing the recorded layout.
+func SaveLayout(ctx context.Context, db *sqlx.DB, l Layout) error {
+	_, err := db.ExecContext(ctx, `
+		insert into `+l.Qualified("hibp_layout")+` ("schema_name", "table_name", "partitions", "method")
+		values ($1, $2, $3, $4)
+		on conflict ("layout_id") do update set
+			"schema_name" = excluded."schema_name",
+			"table_name" = excluded."table_name",
+			"partitions" = excluded."partitions",
+			"method" = excluded."method"`,
+		l.Schema, l.Table, l.Partitions, l.Method)
+	return err
+}
@@ -0,0 +1,311 @@
+// Package jwtauth verifies JWT bearer tokens issued by an OIDC provider,
+// with the keys of a JWKS URL or a local key file.
+package jwtauth
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leesalminen/hibp/model"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// MethodJWT is the principal method of JWT bearer authentication.
+const MethodJWT = "jwt"
+
+const (
+	defaultRefreshInterval = time.Hour
+	// minRefreshInterval limits the JWKS fetches caused by tokens with unknown key IDs.
+	minRefreshInterval = time.Minute
+	defaultLeeway      = time.Minute
+)
+
+// allowedAlgorithms are the asymmetric signature algorithms tokens may use.
+var allowedAlgorithms = map[string]bool{
+	string(jose.RS256): true, string(jose.RS384): true, string(jose.RS512): true,
+	string(jose.PS256): true, string(jose.PS384): true, string(jose.PS512): true,
+	string(jose.ES256): true, string(jose.ES384): true, string(jose.ES512): true,
+	string(jose.EdDSA): true,
+}
+
+// ErrInvalidToken is returned for tokens that fail verification.
+var ErrInvalidToken = errors.New("invalid bearer token")
+
+// Options configure a Verifier. Exactly one of JWKSURL and KeyFile is required.
+type Options struct {
+	// JWKSURL serves the signing keys, e.g. the jwks_uri of an OIDC provider.
+	JWKSURL string
+	// KeyFile holds the signing keys as a JWKS document or PEM public keys.
+	KeyFile string
+	// Issuer is the expected iss claim, if set.
+	Issuer string
+	// Audience must be contained in the aud claim, if set.
+	Audience string
+	// Scopes must all be granted in the scope or scp claim.
+	Scopes []string
+	// NameClaim identifies the client in logs. Defaults to sub.
+	NameClaim string
+	// RefreshInterval of the JWKS URL keys. Defaults to an hour.
+	RefreshInterval time.Duration
+	// Leeway for the exp, nbf and iat claims. Defaults to a minute.
+	Leeway time.Duration
+	// HTTPClient fetches the JWKS URL. Defaults to a client with a 10 second timeout.
+	HTTPClient *http.Client
+}
+
+// Verifier verifies tokens. It is safe for concurrent use.
+type Verifier struct {
+	options Options
+
+	mu        sync.Mutex
+	keySet    jose.JSONWebKeySet
+	fetchedAt time.Time
+}
+
+// New creates a verifier and loads its keys.
+func New(ctx context.Context, options Options) (*Verifier, error) {
+	if (options.JWKSURL == "") == (options.KeyFile == "") {
+		return nil, fmt.Errorf("exactly one of a JWKS URL and a key file is required")
+	}
+	if options.NameClaim == "" {
+		options.NameClaim = "sub"
+	}
+	if options.RefreshInterval == 0 {
+		options.RefreshInterval = defaultRefreshInterval
+	}
+	if options.Leeway == 0 {
+		options.Leeway = defaultLeeway
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	v := &Verifier{options: options}
+	if options.KeyFile != "" {
+		keys, err := readKeyFile(options.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		v.keySet = keys
+		return v, nil
+	}
+
+	if err := v.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+type extraClaims struct {
+	Scope string          `json:"scope"`
+	Scp   json.RawMessage `json:"scp"`
+}
+
+// Verify checks the signature and claims of token and returns its principal.
+// Failed verifications wrap ErrInvalidToken.
+func (v *Verifier) Verify(ctx context.Context, token string) (*model.Principal, error) {
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if len(tok.Headers) != 1 || !allowedAlgorithms[tok.Headers[0].Algorithm] {
+		return nil, fmt.Errorf("%w: unsupported signature algorithm", ErrInvalidToken)
+	}
+
+	keys, err := v.keys(ctx, tok.Headers[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var claims jwt.Claims
+	var extra extraClaims
+	var all map[string]interface{}
+	for _, key := range keys {
+		if err = tok.Claims(key.Key, &claims, &extra, &all); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	expected := jwt.Expected{Issuer: v.options.Issuer, Time: time.Now()}
+	if v.options.Audience != "" {
+		expected.Audience = jwt.Audience{v.options.Audience}
+	}
+	if err := claims.ValidateWithLeeway(expected, v.options.Leeway); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Expiry == nil {
+		return nil, fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+
+	granted := scopes(extra)
+	for _, scope := range v.options.Scopes {
+		if !granted[scope] {
+			return nil, fmt.Errorf("%w: missing scope %s", ErrInvalidToken, scope)
+		}
+	}
+
+	name, _ := all[v.options.NameClaim].(string)
+	if name == "" {
+		return nil, fmt.Errorf("%w: missing %s claim", ErrInvalidToken, v.options.NameClaim)
+	}
+	return &model.Principal{Name: name, Method: MethodJWT}, nil
+}
+
+// scopes returns the scopes of the space separated scope claim
+// and the scp claim, which is either a string or a list.
+func scopes(extra extraClaims) map[string]bool {
+	granted := make(map[string]bool)
+	for _, scope := range strings.Fields(extra.Scope) {
+		granted[scope] = true
+	}
+
+	var list []string
+	if err := json.Unmarshal(extra.Scp, &list); err != nil {
+		var s string
+		if json.Unmarshal(extra.Scp, &s) == nil {
+			list = strings.Fields(s)
+		}
+	}
+	for _, scope := range list {
+		granted[scope] = true
+	}
+	return granted
+}
+
+// keys returns the verification keys matching a token header. Unknown key
+// IDs trigger a refresh of the JWKS URL, at most once per minRefreshInterval.
+func (v *Verifier) keys(ctx context.Context, header jose.Header) ([]jose.JSONWebKey, error) {
+	v.mu.Lock()
+	stale := v.options.JWKSURL != "" && time.Since(v.fetchedAt) > v.options.RefreshInterval
+	keys := findKeys(v.keySet, header)
+	if len(keys) == 0 && v.options.JWKSURL != "" && time.Since(v.fetchedAt) > minRefreshInterval {
+		stale = true
+	}
+	v.mu.Unlock()
+
+	if stale {
+		if err := v.refresh(ctx); err != nil {
+			// keep verifying with the known keys
+			fmt.Fprintln(os.Stderr, "error refreshing JWKS", err)
+		}
+		v.mu.Lock()
+		keys = findKeys(v.keySet, header)
+		v.mu.Unlock()
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, header.KeyID)
+	}
+	return keys, nil
+}
+
+// findKeys returns the signing keys matching the key ID and algorithm of header.
+// Without a key ID, every signing key is a candidate.
+func findKeys(keySet jose.JSONWebKeySet, header jose.Header) []jose.JSONWebKey {
+	var keys []jose.JSONWebKey
+	for _, key := range keySet.Keys {
+		if header.KeyID != "" && key.KeyID != header.KeyID {
+			continue
+		}
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if key.Algorithm != "" && key.Algorithm != header.Algorithm {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// refresh fetches the keys of the JWKS URL.
+func (v *Verifier) refresh(ctx context.Context) error {
+	v.mu.Lock()
+	// failed fetches are not retried before minRefreshInterval either
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.options.JWKSURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.options.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("JWKS request failed with status: %d", resp.StatusCode)
+	}
+
+	var keys jose.JSONWebKeySet
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&keys); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	v.keySet = keys
+	v.mu.Unlock()
+	return nil
+}
+
+// readKeyFile reads a JWKS document or one or more PEM encoded public keys.
+func readKeyFile(path string) (jose.JSONWebKeySet, error) {
+	var keys jose.JSONWebKeySet
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return keys, err
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		err := json.Unmarshal(data, &keys)
+		return keys, err
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		var public interface{}
+		switch block.Type {
+		case "PUBLIC KEY":
+			public, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			cert, err = x509.ParseCertificate(block.Bytes)
+			if err == nil {
+				public = cert.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return keys, err
+		}
+		keys.Keys = append(keys.Keys, jose.JSONWebKey{Key: public, Use: "sig"})
+	}
+
+	if len(keys.Keys) == 0 {
+		return keys, fmt.Errorf("no public keys in %s", path)
+	}
+	return keys, nil
+}
@@ -0,0 +1,236 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	testIssuer   = "https://issuer.test"
+	testAudience = "hibp"
+	testScope    = "hibp:read"
+)
+
+// jwksStandIn serves the public keys of a key set as a JWKS URL.
+type jwksStandIn struct {
+	*httptest.Server
+	fetches int32
+
+	mu   sync.Mutex
+	keys []jose.JSONWebKey
+}
+
+func newJWKSStandIn(t *testing.T, keys ...jose.JSONWebKey) *jwksStandIn {
+	s := &jwksStandIn{}
+	s.rotate(keys...)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.fetches, 1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// rotate replaces the served keys with the public keys of keys.
+func (s *jwksStandIn) rotate(keys ...jose.JSONWebKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = nil
+	for _, key := range keys {
+		s.keys = append(s.keys, key.Public())
+	}
+}
+
+func newRSAKey(t *testing.T, kid string) jose.JSONWebKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jose.JSONWebKey{Key: key, KeyID: kid, Algorithm: string(jose.RS256), Use: "sig"}
+}
+
+func newECKey(t *testing.T, kid string) jose.JSONWebKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jose.JSONWebKey{Key: key, KeyID: kid, Algorithm: string(jose.ES256), Use: "sig"}
+}
+
+// sign returns a token with claims signed by key.
+func sign(t *testing.T, key jose.JSONWebKey, alg jose.SignatureAlgorithm, claims map[string]interface{}) string {
+	t.Helper()
+	options := (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", key.KeyID)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key.Key}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// validClaims returns the claims of a token the test verifiers accept.
+func validClaims() map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "service-a",
+		"scope": "openid " + testScope,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+}
+
+func newTestVerifier(t *testing.T, url string) *Verifier {
+	t.Helper()
+	v, err := New(context.Background(), Options{
+		JWKSURL:  url,
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		Scopes:   []string{testScope},
+		Leeway:   time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	ecKey := newECKey(t, "ec")
+	s := newJWKSStandIn(t, rsaKey, ecKey)
+	v := newTestVerifier(t, s.URL)
+
+	scp := validClaims()
+	delete(scp, "scope")
+	scp["scp"] = []string{testScope}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"RS256", sign(t, rsaKey, jose.RS256, validClaims())},
+		{"ES256", sign(t, ecKey, jose.ES256, validClaims())},
+		{"scp list", sign(t, rsaKey, jose.RS256, scp)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			principal, err := v.Verify(context.Background(), test.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if principal.Name != "service-a" || principal.Method != MethodJWT {
+				t.Errorf("principal %+v, want jwt:service-a", principal)
+			}
+		})
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	s := newJWKSStandIn(t, rsaKey)
+	v := newTestVerifier(t, s.URL)
+
+	claims := func(change func(map[string]interface{})) map[string]interface{} {
+		c := validClaims()
+		change(c)
+		return c
+	}
+
+	// alg=none with the claims of a valid token and no signature
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"rsa","typ":"JWT"}`))
+	payload, _ := json.Marshal(validClaims())
+	none := header + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+
+	// HS256 signed with the public RSA key as the HMAC secret
+	public, err := x509.MarshalPKIXPublicKey(rsaKey.Public().Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	confused := sign(t, jose.JSONWebKey{Key: public, KeyID: "rsa"}, jose.HS256, validClaims())
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"alg none", none},
+		{"HS256 with the public key", confused},
+		{"missing exp", sign(t, rsaKey, jose.RS256, claims(func(c map[string]interface{}) { delete(c, "exp") }))},
+		{"expired", sign(t, rsaKey, jose.RS256, claims(func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() }))},
+		{"wrong scope", sign(t, rsaKey, jose.RS256, claims(func(c map[string]interface{}) { c["scope"] = "hibp:write" }))},
+		{"no scope", sign(t, rsaKey, jose.RS256, claims(func(c map[string]interface{}) { delete(c, "scope") }))},
+		{"wrong issuer", sign(t, rsaKey, jose.RS256, claims(func(c map[string]interface{}) { c["iss"] = "https://other.test" }))},
+		{"wrong audience", sign(t, rsaKey, jose.RS256, claims(func(c map[string]interface{}) { c["aud"] = "other" }))},
+		{"unknown key", sign(t, newRSAKey(t, "rsa"), jose.RS256, validClaims())},
+		{"malformed", "not.a.token"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			principal, err := v.Verify(context.Background(), test.token)
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() = %+v, %v, want ErrInvalidToken", principal, err)
+			}
+		})
+	}
+}
+
+func TestVerifyRotatedKey(t *testing.T) {
+	old := newRSAKey(t, "2024")
+	s := newJWKSStandIn(t, old)
+	v := newTestVerifier(t, s.URL)
+
+	rotated := newRSAKey(t, "2025")
+	s.rotate(rotated)
+	token := sign(t, rotated, jose.RS256, validClaims())
+
+	// unknown key IDs refetch the keys at most once per minRefreshInterval
+	if _, err := v.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify() = %v right after the last fetch, want ErrInvalidToken", err)
+	}
+	if n := atomic.LoadInt32(&s.fetches); n != 1 {
+		t.Fatalf("%d JWKS fetches, want 1", n)
+	}
+
+	v.mu.Lock()
+	v.fetchedAt = time.Now().Add(-minRefreshInterval - time.Second)
+	v.mu.Unlock()
+
+	if _, err := v.Verify(context.Background(), token); err != nil {
+		t.Fatalf("Verify() = %v with the rotated key, want it refetched", err)
+	}
+	if n := atomic.LoadInt32(&s.fetches); n != 2 {
+		t.Errorf("%d JWKS fetches, want 2", n)
+	}
+
+	// the rotated out key is no longer accepted
+	if _, err := v.Verify(context.Background(), sign(t, old, jose.RS256, validClaims())); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() = %v with the rotated out key, want ErrInvalidToken", err)
+	}
+}
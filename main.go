@@ -4,10 +4,17 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/ory/viper"
+	"github.com/leesalminen/hibp/cmd/apikey"
+	"github.com/leesalminen/hibp/cmd/audit"
+	"github.com/leesalminen/hibp/cmd/check"
+	"github.com/leesalminen/hibp/cmd/customlist"
 	"github.com/leesalminen/hibp/cmd/dataimport"
+	"github.com/leesalminen/hibp/cmd/diff"
+	"github.com/leesalminen/hibp/cmd/exclusion"
 	"github.com/leesalminen/hibp/cmd/migrate"
 	"github.com/leesalminen/hibp/cmd/serve"
+	"github.com/leesalminen/hibp/cmd/watchlist"
+	"github.com/ory/viper"
 	"github.com/spf13/cobra"
 )
 
@@ -22,9 +29,16 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
+	rootCmd.AddCommand(apikey.Command)
+	rootCmd.AddCommand(audit.Command)
+	rootCmd.AddCommand(check.Command)
+	rootCmd.AddCommand(customlist.Command)
 	rootCmd.AddCommand(dataimport.Command)
+	rootCmd.AddCommand(diff.Command)
+	rootCmd.AddCommand(exclusion.Command)
 	rootCmd.AddCommand(migrate.Command)
 	rootCmd.AddCommand(serve.Command)
+	rootCmd.AddCommand(watchlist.Command)
 	cobra.OnInitialize(func() {
 		viper.AutomaticEnv()
 	})
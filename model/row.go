@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // Row represents data row.
 type Row struct {
 	PartitionPrefix string `db:"partition_prefix"`
@@ -7,3 +9,72 @@ type Row struct {
 	Hash            string `db:"hash"`
 	Count           int    `db:"count"`
 }
+
+// CustomEntry represents an entry of an organization specific banned password list.
+type CustomEntry struct {
+	HashType  string    `db:"hash_type"`
+	Prefix    string    `db:"prefix"`
+	Hash      string    `db:"hash"`
+	Source    string    `db:"source"`
+	Count     int       `db:"count"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Exclusion represents a hash that must never be reported as pwned.
+type Exclusion struct {
+	HashType  string    `db:"hash_type"`
+	Prefix    string    `db:"prefix"`
+	Hash      string    `db:"hash"`
+	Reason    string    `db:"reason"`
+	CreatedBy string    `db:"created_by"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// ExclusionAudit represents a change to the exclusion list.
+type ExclusionAudit struct {
+	AuditID   int       `db:"audit_id"`
+	Action    string    `db:"action"`
+	HashType  string    `db:"hash_type"`
+	Prefix    string    `db:"prefix"`
+	Hash      string    `db:"hash"`
+	Reason    string    `db:"reason"`
+	Actor     string    `db:"actor"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Import represents a run of data-import.
+type Import struct {
+	ImportID   int        `db:"import_id"`
+	Source     string     `db:"source"`
+	Strategy   string     `db:"strategy"`
+	MinCount   int        `db:"min_count"`
+	Truncated  bool       `db:"truncated"`
+	StartedAt  time.Time  `db:"started_at"`
+	FinishedAt *time.Time `db:"finished_at"`
+}
+
+// APIKey represents a key clients authenticate to the range endpoint with.
+// Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	KeyID        int        `db:"key_id"`
+	Name         string     `db:"name"`
+	KeyHash      string     `db:"key_hash"`
+	Enabled      bool       `db:"enabled"`
+	RateLimit    *float64   `db:"rate_limit"`
+	RateBurst    *int       `db:"rate_burst"`
+	DailyQuota   *int64     `db:"daily_quota"`
+	RequestCount int64      `db:"request_count"`
+	LastUsedAt   *time.Time `db:"last_used_at"`
+	RevokedAt    *time.Time `db:"revoked_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+// Principal identifies the authenticated client of a request.
+type Principal struct {
+	// Name identifies the client in logs and usage statistics.
+	Name string
+	// Method is how the client authenticated, e.g. api-key.
+	Method string
+	// KeyID is the ID of the API key, zero for other methods.
+	KeyID int
+}
@@ -0,0 +1,230 @@
+// Package mtls authorizes clients by their TLS client certificate:
+// subject and SAN allowlists and revocation checks with CRLs or OCSP.
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// MethodCertificate is the principal method of client certificate authentication.
+const MethodCertificate = "cert"
+
+// Supported OCSP modes.
+const (
+	// OCSPOff does not query OCSP responders.
+	OCSPOff = "off"
+	// OCSPSoft rejects revoked certificates but accepts them when the responder can't be reached.
+	OCSPSoft = "soft"
+	// OCSPHard rejects certificates whose status can't be determined.
+	OCSPHard = "hard"
+)
+
+// Options configure a Verifier.
+type Options struct {
+	// AllowedSubjects match the common name or the full distinguished name of the subject.
+	AllowedSubjects []string
+	// AllowedSANs match DNS, email, IP and URI subject alternative names.
+	// A leading "*." matches any DNS name below the domain.
+	AllowedSANs []string
+	// CRLFiles are PEM or DER encoded CRLs, reloaded when they change.
+	CRLFiles []string
+	// AllowStaleCRLs keeps checking against CRLs past their next update
+	// instead of rejecting the certificates of their issuer.
+	AllowStaleCRLs bool
+	// OCSP is OCSPOff, OCSPSoft or OCSPHard.
+	OCSP string
+	// HTTPClient queries OCSP responders. Defaults to a client with a 5 second timeout.
+	HTTPClient *http.Client
+}
+
+// Verifier checks the client certificates of TLS connections.
+// With empty allowlists every certificate issued by the client CAs is allowed.
+type Verifier struct {
+	options Options
+	crls    *crlSet
+	ocsp    *ocspCache
+}
+
+// New creates a verifier and loads its CRLs.
+func New(options Options) (*Verifier, error) {
+	switch options.OCSP {
+	case "":
+		options.OCSP = OCSPOff
+	case OCSPOff, OCSPSoft, OCSPHard:
+	default:
+		return nil, fmt.Errorf("invalid OCSP mode %q, expected off, soft or hard", options.OCSP)
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	v := &Verifier{
+		options: options,
+		crls:    newCRLSet(options.CRLFiles, options.AllowStaleCRLs),
+		ocsp:    newOCSPCache(options.HTTPClient),
+	}
+	if err := v.crls.load(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Configure installs the verifier on a TLS configuration. With optional
+// client certificates, connections without one are passed on unchecked.
+func (v *Verifier) Configure(config *tls.Config, optional bool) {
+	if config.ClientCAs != nil && optional {
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	config.VerifyConnection = v.VerifyConnection
+}
+
+// VerifyConnection rejects client certificates that are not allowed or revoked.
+func (v *Verifier) VerifyConnection(cs tls.ConnectionState) error {
+	if len(cs.VerifiedChains) == 0 || len(cs.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	chain := cs.VerifiedChains[0]
+	leaf := chain[0]
+
+	if !v.allowed(leaf) {
+		return fmt.Errorf("client certificate %s is not allowed", Identity(leaf))
+	}
+
+	// check every certificate below the root against its issuer:
+	for i := 0; i+1 < len(chain); i++ {
+		if err := v.checkRevocation(chain[i], chain[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allowed reports whether the subject or a SAN of cert is on an allowlist.
+func (v *Verifier) allowed(cert *x509.Certificate) bool {
+	if len(v.options.AllowedSubjects) == 0 && len(v.options.AllowedSANs) == 0 {
+		return true
+	}
+
+	for _, subject := range v.options.AllowedSubjects {
+		if subject == cert.Subject.CommonName || subject == cert.Subject.String() {
+			return true
+		}
+	}
+
+	for _, san := range v.options.AllowedSANs {
+		for _, name := range cert.DNSNames {
+			if matchDNS(san, name) {
+				return true
+			}
+		}
+		for _, email := range cert.EmailAddresses {
+			if strings.EqualFold(san, email) {
+				return true
+			}
+		}
+		for _, ip := range cert.IPAddresses {
+			if san == ip.String() {
+				return true
+			}
+		}
+		for _, uri := range cert.URIs {
+			if san == uri.String() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchDNS matches a DNS name against an allowlist entry, "*.example.com"
+// matches names of any depth below example.com.
+func matchDNS(pattern, name string) bool {
+	pattern, name = strings.ToLower(pattern), strings.ToLower(name)
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(name, pattern[1:])
+	}
+	return pattern == name
+}
+
+// checkRevocation checks cert, issued by issuer, against the CRLs and OCSP.
+func (v *Verifier) checkRevocation(cert, issuer *x509.Certificate) error {
+	revoked, err := v.crls.revoked(cert, issuer)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return fmt.Errorf("certificate %s is revoked", Identity(cert))
+	}
+
+	if v.options.OCSP == OCSPOff || len(cert.OCSPServer) == 0 {
+		return nil
+	}
+
+	revoked, err = v.ocsp.revoked(cert, issuer)
+	if err != nil {
+		if v.options.OCSP == OCSPHard {
+			return fmt.Errorf("OCSP status of %s is unknown: %w", Identity(cert), err)
+		}
+		fmt.Fprintln(os.Stderr, "OCSP status of", Identity(cert), "is unknown, accepting it:", err)
+		return nil
+	}
+	if revoked {
+		return fmt.Errorf("certificate %s is revoked", Identity(cert))
+	}
+	return nil
+}
+
+// Reload reloads the CRL files that changed, keeping the previous CRLs if that fails.
+func (v *Verifier) Reload() error {
+	return v.crls.load()
+}
+
+// Run reloads changed CRL files every interval until ctx is done.
+func (v *Verifier) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := v.Reload(); err != nil {
+				fmt.Fprintln(os.Stderr, "error reloading CRLs, keeping the previous ones:", err)
+			}
+		}
+	}
+}
+
+// Identity names the client of a certificate: its first URI, DNS or email
+// SAN, or else its subject common name.
+func Identity(cert *x509.Certificate) string {
+	switch {
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	case len(cert.EmailAddresses) > 0:
+		return cert.EmailAddresses[0]
+	default:
+		return cert.Subject.CommonName
+	}
+}
+
+// Principal returns the principal of the verified client certificate of r,
+// nil if the request has none.
+func Principal(r *http.Request) *model.Principal {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return &model.Principal{Name: Identity(r.TLS.VerifiedChains[0][0]), Method: MethodCertificate}
+}
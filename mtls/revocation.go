@@ -0,0 +1,212 @@
+package mtls
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// ErrUnknownFormat is returned for CRL files that are neither PEM nor DER.
+var ErrUnknownFormat = errors.New("unknown CRL format")
+
+// crlSet holds the CRLs of a list of files.
+type crlSet struct {
+	files      []string
+	allowStale bool
+
+	mu       sync.RWMutex
+	modified map[string]time.Time
+	lists    map[string]*crl
+}
+
+func newCRLSet(files []string, allowStale bool) *crlSet {
+	return &crlSet{
+		files:      files,
+		allowStale: allowStale,
+		modified:   make(map[string]time.Time),
+		lists:      make(map[string]*crl),
+	}
+}
+
+// load reads the files that changed since the last load.
+func (s *crlSet) load() error {
+	for _, file := range s.files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+
+		s.mu.RLock()
+		unchanged := info.ModTime().Equal(s.modified[file])
+		s.mu.RUnlock()
+		if unchanged {
+			continue
+		}
+
+		list, err := readCRL(file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path.Base(file), err)
+		}
+
+		s.mu.Lock()
+		s.lists[file] = list
+		s.modified[file] = info.ModTime()
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// revoked reports whether a CRL signed by issuer lists cert. A CRL past its
+// next update fails the check unless stale CRLs are allowed.
+func (s *crlSet) revoked(cert, issuer *x509.Certificate) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for file, list := range s.lists {
+		if !list.issuedBy(issuer) {
+			continue
+		}
+		if list.revoked[cert.SerialNumber.String()] {
+			return true, nil
+		}
+		if list.expired(time.Now()) {
+			if !s.allowStale {
+				return false, fmt.Errorf("CRL %s is past its next update", path.Base(file))
+			}
+			fmt.Fprintln(os.Stderr, "CRL", path.Base(file), "is past its next update")
+		}
+	}
+	return false, nil
+}
+
+// crl is a parsed certificate revocation list.
+type crl struct {
+	list    *x509.RevocationList
+	revoked map[string]bool
+}
+
+// readCRL reads a PEM or DER encoded CRL file.
+func readCRL(file string) (*crl, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != "X509 CRL" {
+			return nil, ErrUnknownFormat
+		}
+		data = block.Bytes
+	}
+
+	list, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := make(map[string]bool, len(list.RevokedCertificateEntries))
+	for _, entry := range list.RevokedCertificateEntries {
+		revoked[entry.SerialNumber.String()] = true
+	}
+	return &crl{list: list, revoked: revoked}, nil
+}
+
+// issuedBy reports whether issuer signed the CRL.
+func (c *crl) issuedBy(issuer *x509.Certificate) bool {
+	if !bytes.Equal(c.list.RawIssuer, issuer.RawSubject) {
+		return false
+	}
+	return c.list.CheckSignatureFrom(issuer) == nil
+}
+
+func (c *crl) expired(now time.Time) bool {
+	return !c.list.NextUpdate.IsZero() && now.After(c.list.NextUpdate)
+}
+
+// ocspTTL is how long a response without a next update is cached.
+const ocspTTL = time.Hour
+
+type ocspEntry struct {
+	revoked bool
+	expires time.Time
+}
+
+// ocspCache queries OCSP responders and caches their answers until the next update.
+type ocspCache struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	entries map[string]ocspEntry
+}
+
+func newOCSPCache(client *http.Client) *ocspCache {
+	return &ocspCache{
+		client:  client,
+		entries: make(map[string]ocspEntry),
+	}
+}
+
+// revoked asks the OCSP responder of cert whether it is revoked.
+func (c *ocspCache) revoked(cert, issuer *x509.Certificate) (bool, error) {
+	key := issuer.Subject.String() + "/" + cert.SerialNumber.String()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.revoked, nil
+	}
+
+	request, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return false, err
+	}
+
+	var lastErr error
+	for _, server := range cert.OCSPServer {
+		resp, err := c.client.Post(server, "application/ocsp-request", bytes.NewReader(request))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("OCSP request failed with status: %d", resp.StatusCode)
+			continue
+		}
+
+		parsed, err := ocsp.ParseResponseForCert(body, cert, issuer)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if parsed.Status == ocsp.Unknown {
+			lastErr = fmt.Errorf("responder does not know the certificate")
+			continue
+		}
+
+		entry := ocspEntry{revoked: parsed.Status == ocsp.Revoked, expires: time.Now().Add(ocspTTL)}
+		if !parsed.NextUpdate.IsZero() {
+			entry.expires = parsed.NextUpdate
+		}
+		c.mu.Lock()
+		c.entries[key] = entry
+		c.mu.Unlock()
+		return entry.revoked, nil
+	}
+	return false, lastErr
+}
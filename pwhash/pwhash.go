@@ -0,0 +1,76 @@
+// Package pwhash computes the password hashes served by the range API.
+package pwhash
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+// Supported hash types.
+const (
+	TypeSHA1 = "sha1"
+	TypeNTLM = "ntlm"
+)
+
+// PrefixLength is the length of the k-anonymity prefix of a hash.
+const PrefixLength = 5
+
+// SHA1 returns the upper case hex SHA-1 hash of password.
+func SHA1(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// NTLM returns the upper case hex NTLM hash of password,
+// the MD4 hash of its UTF-16LE encoding.
+func NTLM(password string) string {
+	encoded := utf16.Encode([]rune(password))
+	buf := make([]byte, 2*len(encoded))
+	for i, c := range encoded {
+		binary.LittleEndian.PutUint16(buf[2*i:], c)
+	}
+
+	h := md4.New()
+	h.Write(buf)
+	return strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+}
+
+// Hash returns the hash of password for hashType.
+func Hash(hashType, password string) string {
+	if hashType == TypeNTLM {
+		return NTLM(password)
+	}
+	return SHA1(password)
+}
+
+// Length returns the length of a hex encoded hash of hashType.
+func Length(hashType string) int {
+	if hashType == TypeNTLM {
+		return 32
+	}
+	return 40
+}
+
+// Valid reports whether hash is a hex encoded hash of hashType.
+// Both upper and lower case characters are accepted.
+func Valid(hashType, hash string) bool {
+	if hashType != TypeSHA1 && hashType != TypeNTLM {
+		return false
+	}
+	if len(hash) != Length(hashType) {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// Split returns the upper case k-anonymity prefix and suffix of hash.
+func Split(hash string) (string, string) {
+	hash = strings.ToUpper(hash)
+	return hash[:PrefixLength], hash[PrefixLength:]
+}
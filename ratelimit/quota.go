@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Quotas counts the requests of API keys per UTC day in the database. Every
+// request is counted with a single statement, so serve instances sharing a
+// database never exceed a quota together.
+type Quotas struct {
+	db     *sqlx.DB
+	schema string
+}
+
+// NewQuotas creates daily quotas stored in the hibp_api_key_usage table of schema.
+func NewQuotas(db *sqlx.DB, schema string) *Quotas {
+	return &Quotas{db: db, schema: schema}
+}
+
+// Allow counts a request of the key and reports whether it is within quota.
+// Requests over quota are not counted.
+func (q *Quotas) Allow(ctx context.Context, keyID int, quota int64) (bool, error) {
+	day := time.Now().UTC().Format("2006-01-02")
+
+	var requests int64
+	err := q.db.GetContext(ctx, &requests, `
+		insert into `+q.schema+`.hibp_api_key_usage as u ("key_id", "day", "requests")
+		values ($1, $2, 1)
+		on conflict ("key_id", "day") do update
+		set "requests" = u."requests" + 1
+		where u."requests" < $3
+		returning "requests"`, keyID, day, quota)
+	if err == sql.ErrNoRows {
+		// the update was skipped, the quota is used up
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return requests <= quota, nil
+}
@@ -0,0 +1,292 @@
+// Package ratelimit limits the requests of every client of the range
+// endpoint, identified by API key, client certificate or IP address.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leesalminen/hibp/apikey"
+	"github.com/leesalminen/hibp/model"
+	"github.com/leesalminen/hibp/mtls"
+	"golang.org/x/time/rate"
+)
+
+// idleTimeout is how long the limiter of an inactive client is kept.
+const idleTimeout = 10 * time.Minute
+
+// Options configure a Limiter.
+type Options struct {
+	// Rate is the default number of requests per second of a client, zero means unlimited.
+	Rate float64
+	// Burst is the default number of requests allowed at once, zero uses the rate rounded up.
+	Burst int
+	// TrustedProxies are the networks whose X-Forwarded-For header is honored.
+	TrustedProxies []*net.IPNet
+	// Keys identifies API key clients and their limits. Nil limits every client by IP address.
+	Keys *apikey.Authenticator
+	// Quotas enforces the daily quotas of API keys. Nil disables quotas.
+	Quotas *Quotas
+}
+
+type client struct {
+	limiter *rate.Limiter
+	seen    time.Time
+}
+
+// Limiter is http.Handler middleware answering 429 Too Many Requests to
+// clients that exceed their rate limit or daily quota.
+type Limiter struct {
+	options Options
+
+	mu      sync.Mutex
+	clients map[string]*client
+}
+
+// New creates a limiter with options.
+func New(options Options) *Limiter {
+	return &Limiter{
+		options: options,
+		clients: make(map[string]*client),
+	}
+}
+
+// Middleware limits the requests passed to next.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, limit, burst, quota := l.identify(r)
+
+		if limit > 0 {
+			if delay := l.reserve(id, limit, burst); delay > 0 {
+				tooManyRequests(w, "Rate limit is exceeded", delay)
+				return
+			}
+		}
+
+		if quota.keyID != 0 && quota.limit > 0 && l.options.Quotas != nil {
+			allowed, err := l.options.Quotas.Allow(r.Context(), quota.keyID, quota.limit)
+			if err != nil {
+				// a quota that can't be checked does not block the client
+				fmt.Fprintln(os.Stderr, "error checking daily quota of", id, err)
+			} else if !allowed {
+				tooManyRequests(w, "Daily quota is exceeded", untilTomorrow(time.Now()))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type quota struct {
+	keyID int
+	limit int64
+}
+
+// identify returns the client of r with its limits: its API key, else its
+// client certificate, else its IP address. Clients with an unknown or
+// invalid API key are limited like clients without one, the key is rejected later.
+func (l *Limiter) identify(r *http.Request) (string, rate.Limit, int, quota) {
+	limit, burst := l.options.Rate, l.options.Burst
+
+	if token := r.Header.Get(apikey.Header); token != "" && l.options.Keys != nil {
+		key, err := l.options.Keys.Key(r.Context(), token)
+		if err != nil && err != apikey.ErrInvalidKey {
+			fmt.Fprintln(os.Stderr, "error verifying API key for rate limiting", err)
+		}
+		if err == nil {
+			return "key:" + strconv.Itoa(key.KeyID), keyLimit(key, limit), keyBurst(key, limit, burst), keyQuota(key)
+		}
+	}
+
+	if principal := mtls.Principal(r); principal != nil {
+		return "cert:" + principal.Name, rate.Limit(limit), burstOf(limit, burst), quota{}
+	}
+
+	return "ip:" + ClientIP(r, l.options.TrustedProxies), rate.Limit(limit), burstOf(limit, burst), quota{}
+}
+
+func keyLimit(key *model.APIKey, limit float64) rate.Limit {
+	if key.RateLimit != nil {
+		return rate.Limit(*key.RateLimit)
+	}
+	return rate.Limit(limit)
+}
+
+func keyBurst(key *model.APIKey, limit float64, burst int) int {
+	if key.RateBurst != nil {
+		return *key.RateBurst
+	}
+	return burstOf(float64(keyLimit(key, limit)), burst)
+}
+
+func keyQuota(key *model.APIKey) quota {
+	q := quota{keyID: key.KeyID}
+	if key.DailyQuota != nil {
+		q.limit = *key.DailyQuota
+	}
+	return q
+}
+
+// burstOf returns burst, or limit rounded up if burst is not set.
+func burstOf(limit float64, burst int) int {
+	if burst > 0 {
+		return burst
+	}
+	return int(math.Max(1, math.Ceil(limit)))
+}
+
+// reserve takes a token of the client id and returns how long the client
+// has to wait for it, zero if the request is allowed.
+func (l *Limiter) reserve(id string, limit rate.Limit, burst int) time.Duration {
+	now := time.Now()
+
+	l.mu.Lock()
+	c, ok := l.clients[id]
+	if !ok {
+		c = &client{limiter: rate.NewLimiter(limit, burst)}
+		l.clients[id] = c
+	}
+	c.seen = now
+	l.mu.Unlock()
+
+	// the limits of a key may have changed:
+	if c.limiter.Limit() != limit {
+		c.limiter.SetLimitAt(now, limit)
+	}
+	if c.limiter.Burst() != burst {
+		c.limiter.SetBurstAt(now, burst)
+	}
+
+	reservation := c.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return time.Second
+	}
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		reservation.CancelAt(now)
+	}
+	return delay
+}
+
+// Run evicts idle clients every interval until ctx is done.
+func (l *Limiter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			l.evict(now)
+		}
+	}
+}
+
+// evict removes the limiters of clients idle for longer than idleTimeout.
+func (l *Limiter) evict(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for id, c := range l.clients {
+		if now.Sub(c.seen) > idleTimeout {
+			delete(l.clients, id)
+		}
+	}
+}
+
+// tooManyRequests answers 429 with the Retry-After header in whole seconds.
+func tooManyRequests(w http.ResponseWriter, reason string, delay time.Duration) {
+	seconds := int(math.Ceil(delay.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusTooManyRequests)
+	fmt.Fprintf(w, "%s. Try again in %d seconds.", reason, seconds)
+}
+
+// untilTomorrow returns the time until the next UTC day, when daily quotas reset.
+func untilTomorrow(now time.Time) time.Duration {
+	now = now.UTC()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	return tomorrow.Sub(now)
+}
+
+// ParseCIDRs parses trusted proxy networks. A plain IP address is a single host network.
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", value)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// ClientIP returns the IP address of the client of r. X-Forwarded-For is
+// only honored when the request comes from a trusted proxy; the header is
+// read from the right and the first address that is not a trusted proxy
+// is the client.
+func ClientIP(r *http.Request, trusted []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !isTrusted(host, trusted) {
+		return host
+	}
+
+	var forwarded []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(addr) == nil {
+			// a malformed entry can't be trusted, neither can anything left of it
+			return host
+		}
+		if !isTrusted(addr, trusted) {
+			return addr
+		}
+		host = addr
+	}
+	return host
+}
+
+func isTrusted(addr string, trusted []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
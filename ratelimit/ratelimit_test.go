@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+func TestKeyBurst(t *testing.T) {
+	rate, burst := 10.0, 7
+
+	tests := []struct {
+		name  string
+		key   model.APIKey
+		limit float64
+		burst int
+		want  int
+	}{
+		{"default limit rounded up", model.APIKey{}, 2.5, 0, 3},
+		{"default burst", model.APIKey{}, 2.5, 5, 5},
+		{"key limit rounded up", model.APIKey{RateLimit: &rate}, 2.5, 0, 10},
+		{"key burst", model.APIKey{RateLimit: &rate, RateBurst: &burst}, 2.5, 5, 7},
+		{"below one request", model.APIKey{}, 0.1, 0, 1},
+	}
+
+	for _, test := range tests {
+		if got := keyBurst(&test.key, test.limit, test.burst); got != test.want {
+			t.Errorf("%s: keyBurst() = %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []string
+		wantErr bool
+	}{
+		{"networks", []string{"10.0.0.0/8", " fd00::/8"}, []string{"10.0.0.0/8", "fd00::/8"}, false},
+		{"single IPv4 address", []string{"192.0.2.1"}, []string{"192.0.2.1/32"}, false},
+		{"single IPv6 address", []string{"2001:db8::1"}, []string{"2001:db8::1/128"}, false},
+		{"invalid address", []string{"proxy.example.com"}, nil, true},
+		{"invalid network", []string{"10.0.0.0/33"}, nil, true},
+	}
+
+	for _, test := range tests {
+		networks, err := ParseCIDRs(test.values)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: ParseCIDRs() error = %v, want error %v", test.name, err, test.wantErr)
+			continue
+		}
+		if len(networks) != len(test.want) {
+			t.Errorf("%s: ParseCIDRs() = %v, want %v", test.name, networks, test.want)
+			continue
+		}
+		for i, network := range networks {
+			if network.String() != test.want[i] {
+				t.Errorf("%s: ParseCIDRs()[%d] = %s, want %s", test.name, i, network, test.want[i])
+			}
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseCIDRs([]string{"10.0.0.0/8", "2001:db8::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"no proxy", "192.0.2.7:4321", nil, "192.0.2.7"},
+		{"untrusted peer sending the header", "192.0.2.7:4321", []string{"198.51.100.1"}, "192.0.2.7"},
+		{"trusted proxy", "10.0.0.1:4321", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"trusted IPv6 proxy", "[2001:db8::1]:4321", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"trusted proxy without the header", "10.0.0.1:4321", nil, "10.0.0.1"},
+		{"multiple hops through trusted proxies", "10.0.0.1:4321", []string{"198.51.100.1, 10.0.0.2, 10.0.0.3"}, "198.51.100.1"},
+		{"spoofed entries left of the client", "10.0.0.1:4321", []string{"203.0.113.9, 198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"repeated headers", "10.0.0.1:4321", []string{"203.0.113.9, 198.51.100.1", "10.0.0.2"}, "198.51.100.1"},
+		{"only trusted hops", "10.0.0.1:4321", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"malformed entry", "10.0.0.1:4321", []string{"198.51.100.1, unknown, 10.0.0.2"}, "10.0.0.2"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/range/21BD1", nil)
+		r.RemoteAddr = test.remoteAddr
+		for _, header := range test.forwarded {
+			r.Header.Add("X-Forwarded-For", header)
+		}
+		if got := ClientIP(r, trusted); got != test.want {
+			t.Errorf("%s: ClientIP() = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+// Range returns the HIBP rows of the hashType prefix sorted by hash.
+// A hash imported from several sources is reported once with the summed count.
+func (s *Store) Range(ctx context.Context, hashType, prefix string) ([]model.Row, error) {
+	rows, err := s.db.NamedQueryContext(ctx, `
+		select "hash", sum("count") as "count"
+		from `+s.options.Layout.QualifiedTable(hashType)+`
+		where "partition_prefix" = :partition_prefix
+		and "prefix" = :prefix
+		group by "hash"
+		having sum("count") >= :min_count
+		order by "hash" collate "C"`,
+		map[string]interface{}{
+			"partition_prefix": s.options.Layout.Key(prefix),
+			"prefix":           prefix,
+			"min_count":        s.options.MinCount,
+		})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []model.Row
+	for rows.Next() {
+		row := model.Row{}
+		if err := rows.StructScan(&row); err != nil {
+			return nil, err
+		}
+		found = append(found, row)
+	}
+	return found, rows.Err()
+}
+
+// MergeCustom adds the custom list entries of prefix to rows. Entries from
+// several sources are summed and combined with the HIBP count like MergeCount.
+func (s *Store) MergeCustom(ctx context.Context, hashType, prefix string, rows []model.Row) ([]model.Row, error) {
+	var custom []model.Row
+	err := s.db.SelectContext(ctx, &custom, `
+		select "hash", sum("count") as "count"
+		from `+s.options.Layout.Qualified("hibp_custom")+`
+		where "hash_type" = $1 and "prefix" = $2
+		group by "hash"`, hashType, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(custom) == 0 {
+		return rows, nil
+	}
+
+	index := make(map[string]int, len(rows))
+	for i, row := range rows {
+		index[row.Hash] = i
+	}
+
+	for _, entry := range custom {
+		i, ok := index[entry.Hash]
+		if !ok {
+			rows = append(rows, model.Row{
+				PartitionPrefix: s.options.Layout.Key(prefix),
+				Prefix:          prefix,
+				Hash:            entry.Hash,
+				Count:           s.MergeCount(0, entry.Count),
+			})
+			continue
+		}
+		rows[i].Count = s.MergeCount(rows[i].Count, entry.Count)
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Hash < rows[j].Hash })
+	return rows, nil
+}
+
+// FilterMinCount removes the rows seen fewer times than Options.MinCount,
+// like Range does for the stored rows.
+func (s *Store) FilterMinCount(rows []model.Row) []model.Row {
+	if s.options.MinCount <= 0 {
+		return rows
+	}
+	filtered := rows[:0]
+	for _, row := range rows {
+		if row.Count >= s.options.MinCount {
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered
+}
+
+// FilterExcluded removes the hashes on the exclusion list from rows.
+func (s *Store) FilterExcluded(ctx context.Context, hashType, prefix string, rows []model.Row) ([]model.Row, error) {
+	var excluded []string
+	err := s.db.SelectContext(ctx, &excluded, `
+		select "hash"
+		from `+s.options.Layout.Qualified("hibp_exclusion")+`
+		where "hash_type" = $1 and "prefix" = $2`, hashType, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(excluded) == 0 {
+		return rows, nil
+	}
+
+	skip := make(map[string]bool, len(excluded))
+	for _, hash := range excluded {
+		skip[hash] = true
+	}
+
+	filtered := rows[:0]
+	for _, row := range rows {
+		if !skip[row.Hash] {
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/leesalminen/hibp/model"
+)
+
+func TestFilterMinCount(t *testing.T) {
+	rows := func() []model.Row {
+		return []model.Row{{Hash: "A", Count: 1}, {Hash: "B", Count: 5}, {Hash: "C", Count: 10}}
+	}
+
+	tests := []struct {
+		minCount int
+		want     []string
+	}{
+		{0, []string{"A", "B", "C"}},
+		{1, []string{"A", "B", "C"}},
+		{5, []string{"B", "C"}},
+		{11, nil},
+	}
+
+	for _, test := range tests {
+		s := New(nil, Options{MinCount: test.minCount})
+		got := s.FilterMinCount(rows())
+		if len(got) != len(test.want) {
+			t.Errorf("min count %d kept %v, want %v", test.minCount, got, test.want)
+			continue
+		}
+		for i, row := range got {
+			if row.Hash != test.want[i] {
+				t.Errorf("min count %d kept %v, want %v", test.minCount, got, test.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package store looks up password hashes in the database.
+package store
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/pwhash"
+)
+
+// Options configure how counts are reported.
+type Options struct {
+	// MinCount hides HIBP hashes seen fewer times.
+	MinCount int
+	// CustomCount replaces the count of custom list entries when positive,
+	// otherwise custom list counts are added to the HIBP count.
+	CustomCount int
+	// Layout of the HIBP tables, dataset.DefaultLayout if unset.
+	Layout dataset.Layout
+}
+
+// Store looks up hashes in the HIBP tables, merged with the custom lists
+// and filtered by the exclusion list.
+type Store struct {
+	db      *sqlx.DB
+	options Options
+}
+
+// New creates a store on db.
+func New(db *sqlx.DB, options Options) *Store {
+	if options.Layout == (dataset.Layout{}) {
+		options.Layout = dataset.DefaultLayout
+	}
+	return &Store{
+		db:      db,
+		options: options,
+	}
+}
+
+// DB returns the database the store reads from.
+func (s *Store) DB() *sqlx.DB {
+	return s.db
+}
+
+// Layout returns the layout of the HIBP tables.
+func (s *Store) Layout() dataset.Layout {
+	return s.options.Layout
+}
+
+type lookup struct {
+	Count       int  `db:"count"`
+	CustomCount int  `db:"custom_count"`
+	Excluded    bool `db:"excluded"`
+}
+
+// Count returns how often the full hash of hashType was seen,
+// zero if it is not pwned.
+func (s *Store) Count(ctx context.Context, hashType, hash string) (int, error) {
+	prefix, suffix := pwhash.Split(hash)
+
+	var l lookup
+	err := s.db.GetContext(ctx, &l, `
+		select
+			coalesce((
+				select sum("count") from `+s.options.Layout.QualifiedTable(hashType)+`
+				where "partition_prefix" = $4 and "prefix" = $2 and "hash" = $3
+			), 0) as "count",
+			coalesce((
+				select sum("count") from `+s.options.Layout.Qualified("hibp_custom")+`
+				where "hash_type" = $1 and "prefix" = $2 and "hash" = $3
+			), 0) as "custom_count",
+			exists(
+				select 1 from `+s.options.Layout.Qualified("hibp_exclusion")+`
+				where "hash_type" = $1 and "prefix" = $2 and "hash" = $3
+			) as "excluded"`,
+		hashType, prefix, suffix, s.options.Layout.Key(prefix))
+	if err != nil {
+		return 0, err
+	}
+
+	if l.Excluded {
+		return 0, nil
+	}
+	if l.Count < s.options.MinCount {
+		l.Count = 0
+	}
+	return s.MergeCount(l.Count, l.CustomCount), nil
+}
+
+// MergeCount combines the HIBP count of a hash with its custom list count.
+func (s *Store) MergeCount(count, customCount int) int {
+	if customCount == 0 {
+		return count
+	}
+	if s.options.CustomCount > 0 {
+		if count < s.options.CustomCount {
+			return s.options.CustomCount
+		}
+		return count
+	}
+	return count + customCount
+}
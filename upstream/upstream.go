@@ -0,0 +1,193 @@
+// Package upstream fetches password hash ranges from the HIBP API.
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leesalminen/hibp/pwhash"
+)
+
+// DefaultBaseURL is the public HIBP pwned passwords API.
+const DefaultBaseURL = "https://api.pwnedpasswords.com"
+
+const (
+	maxRetries     = 3           // Maximum number of retry attempts
+	initialBackoff = time.Second // Initial backoff duration
+)
+
+// Client fetches ranges from an HIBP compatible API.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// New creates a client for the API at baseURL.
+// A zero timeout means requests do not time out.
+func New(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Range is a range response with its cache validators.
+type Range struct {
+	Lines []string
+	// ETag and LastModified are the validators of the response,
+	// empty if the API did not send them.
+	ETag         string
+	LastModified string
+	// NotModified is set when the range did not change since the
+	// validators passed to FetchRangeIfChanged. Lines is empty then.
+	NotModified bool
+}
+
+// FetchRange fetches the range of hashType for prefix and returns the response lines.
+func (c *Client) FetchRange(ctx context.Context, hashType, prefix string) ([]string, error) {
+	r, err := c.FetchRangeIfChanged(ctx, hashType, prefix, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return r.Lines, nil
+}
+
+// FetchRangeIfChanged fetches the range of hashType for prefix unless it did
+// not change since the response with etag and lastModified. Empty validators
+// are not sent. The request is aborted when ctx is done.
+func (c *Client) FetchRangeIfChanged(ctx context.Context, hashType, prefix, etag, lastModified string) (*Range, error) {
+	url := fmt.Sprintf("%s/range/%s", c.BaseURL, prefix)
+	if hashType == pwhash.TypeNTLM {
+		url += "?mode=ntlm"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	r := &Range{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		r.NotModified = true
+		// some servers omit the validators on 304 responses
+		if r.ETag == "" {
+			r.ETag = etag
+		}
+		if r.LastModified == "" {
+			r.LastModified = lastModified
+		}
+		return r, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Lines = strings.Split(string(body), "\r\n")
+	return r, nil
+}
+
+// FetchRangeWithRetry calls FetchRange, retrying network, rate limit
+// and server errors with exponential backoff.
+func (c *Client) FetchRangeWithRetry(ctx context.Context, hashType, prefix string) ([]string, error) {
+	r, err := c.FetchRangeIfChangedWithRetry(ctx, hashType, prefix, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return r.Lines, nil
+}
+
+// FetchRangeIfChangedWithRetry calls FetchRangeIfChanged, retrying network,
+// rate limit and server errors with exponential backoff. It gives up
+// without retrying once ctx is done.
+func (c *Client) FetchRangeIfChangedWithRetry(ctx context.Context, hashType, prefix, etag, lastModified string) (*Range, error) {
+	var lastErr error
+	backoff := initialBackoff
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+			backoff *= 2 // Exponential backoff
+		}
+
+		r, err := c.FetchRangeIfChanged(ctx, hashType, prefix, etag, lastModified)
+		if err == nil {
+			return r, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		lastErr = err
+
+		// Check for specific errors to retry
+		if _, ok := err.(*url.Error); ok {
+			// Network errors should be retried
+			continue
+		} else if strings.Contains(err.Error(), "GOAWAY") {
+			// Retry on GOAWAY errors
+			continue
+		} else if strings.Contains(err.Error(), "429") {
+			// Rate limit errors should be retried
+			continue
+		} else if strings.Contains(err.Error(), "500") ||
+			strings.Contains(err.Error(), "502") ||
+			strings.Contains(err.Error(), "503") ||
+			strings.Contains(err.Error(), "504") {
+			// Server errors should be retried
+			continue
+		}
+
+		// Don't retry other types of errors
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("after %d attempts, last error: %v", maxRetries+1, lastErr)
+}
+
+// ParseLine splits a SUFFIX:COUNT range response line.
+func ParseLine(line string) (string, int, error) {
+	parts := strings.Split(strings.TrimSpace(line), ":")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("split by ':' did not result in 2 items")
+	}
+
+	count, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("error converting count %s as integer: %v", parts[1], err)
+	}
+
+	return parts[0], count, nil
+}
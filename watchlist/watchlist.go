@@ -0,0 +1,148 @@
+// Package watchlist re-checks registered hashes against the imported dataset
+// and reports the ones that newly appear in it.
+package watchlist
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/leesalminen/hibp/dataset"
+	"github.com/leesalminen/hibp/pwhash"
+)
+
+// Report lists the watched hashes that newly appear in the dataset.
+type Report struct {
+	ImportID   int       `json:"import_id,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+	Checked    int       `json:"checked"`
+	NewlyPwned []Match   `json:"newly_pwned"`
+}
+
+// Match is a watched hash found in the dataset.
+type Match struct {
+	ID       string `json:"id" db:"watch_id"`
+	HashType string `json:"hash_type" db:"hash_type"`
+	Count    int    `json:"count" db:"count"`
+}
+
+type checkedRow struct {
+	Match
+	PreviousCount int `db:"previous_count"`
+}
+
+// Check looks up every watched hash in the tables of layout, stores its
+// current count and returns the hashes that were not pwned before. importID
+// is recorded as the import that first reported a hash, zero if the check is
+// not part of an import.
+func Check(db *sqlx.DB, layout dataset.Layout, importID int) (*Report, error) {
+	report := &Report{
+		ImportID:   importID,
+		CheckedAt:  time.Now().UTC(),
+		NewlyPwned: []Match{},
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hashType := range []string{pwhash.TypeSHA1, pwhash.TypeNTLM} {
+		var rows []checkedRow
+		err := tx.Select(&rows, `
+			select w."watch_id", w."hash_type", w."pwned_count" as "previous_count",
+				coalesce((
+					select sum(h."count")
+					from `+layout.QualifiedTable(hashType)+` h
+					where h."partition_prefix" = left(w."prefix", $2)
+					and h."prefix" = w."prefix"
+					and h."hash" = w."hash"
+				), 0) as "count"
+			from `+layout.Qualified("hibp_watchlist")+` w
+			where w."hash_type" = $1
+			and not exists (
+				select 1 from `+layout.Qualified("hibp_exclusion")+` e
+				where e."hash_type" = w."hash_type" and e."prefix" = w."prefix" and e."hash" = w."hash"
+			)`, hashType, layout.KeyLength())
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		for _, row := range rows {
+			report.Checked++
+
+			newlyPwned := row.Count > 0 && row.PreviousCount == 0
+			if newlyPwned {
+				report.NewlyPwned = append(report.NewlyPwned, row.Match)
+			}
+
+			_, err := tx.Exec(`
+				update `+layout.Qualified("hibp_watchlist")+` set
+					"pwned_count" = $2,
+					"pwned_import" = case when $3 then nullif($4, 0) else "pwned_import" end,
+					"checked_at" = now()
+				where "watch_id" = $1`,
+				row.ID, row.Count, newlyPwned, importID)
+			if err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// WriteReport writes the report as JSON to path.
+func WriteReport(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Notify posts the report as JSON to the webhook at url.
+func Notify(url string, report *Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook request failed with status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Publish writes the report to path and posts it to url when they are set.
+// The webhook is only called when some hashes are newly pwned.
+func Publish(report *Report, path, url string) error {
+	if path != "" {
+		if err := WriteReport(path, report); err != nil {
+			return err
+		}
+	}
+	if url != "" && len(report.NewlyPwned) > 0 {
+		if err := Notify(url, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}